@@ -1,9 +1,14 @@
 package main
 
 import (
+	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	. "clockin/lib"
 
@@ -26,11 +31,269 @@ func getAdditionalOption() string {
 	return option
 }
 
+func getFlag(name string) string {
+	value, _ := lookupFlag(name)
+	return value
+}
+
+func hasFlag(name string) bool {
+	for _, arg := range os.Args {
+		if arg == "--"+name {
+			return true
+		}
+	}
+	return false
+}
+
+// getDateFlag parses a date flag, returning the zero time if it was not given.
+func getDateFlag(name string) (time.Time, error) {
+	value := getFlag(name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	return ParseDate(value)
+}
+
+// getDateWindow returns the date range given by the --from and --to flags,
+// defaulting to the last year up to today.
+func getDateWindow() (time.Time, time.Time, error) {
+	from, err := getDateFlag("from")
+	if err != nil {
+		return from, time.Time{}, err
+	}
+	to, err := getDateFlag("to")
+	if err != nil {
+		return from, to, err
+	}
+	if to.IsZero() {
+		to = CurrentTime()
+	}
+	if from.IsZero() {
+		from = to.AddDate(-1, 0, 0)
+	}
+	return from, to, nil
+}
+
+func getReportOptions() (ReportOptions, error) {
+	from, err := getDateFlag("from")
+	if err != nil {
+		return ReportOptions{}, err
+	}
+	to, err := getDateFlag("to")
+	if err != nil {
+		return ReportOptions{}, err
+	}
+	output := getFlag("output")
+	if output == "" {
+		output = getFlag("o")
+	}
+	groupBy := getFlag("group-by")
+	if groupBy == "" {
+		groupBy = getFlag("by")
+	}
+	return ReportOptions{
+		Period:   getFlag("period"),
+		From:     from,
+		To:       to,
+		GroupBy:  groupBy,
+		Weekdays: hasFlag("weekdays"),
+		Format:   getFlag("format"),
+		Output:   output,
+		Template: getFlag("template"),
+	}, nil
+}
+
+func getStatsOptions() (StatsOptions, error) {
+	options := StatsOptions{Name: getFlag("name"), Tab: getFlag("tab")}
+	if name := getAdditionalOption(); name != "" && !strings.HasPrefix(name, "-") {
+		options.Name = name
+	}
+	if value := getFlag("width"); value != "" {
+		width, err := strconv.Atoi(value)
+		if err != nil || width < 40 {
+			return options, fmt.Errorf("width must be a number of columns of at least 40, not '%s'", value)
+		}
+		options.Width = width
+	}
+	if last := getFlag("last"); last != "" {
+		days, err := ParseDays(last)
+		if err != nil {
+			return options, err
+		}
+		options.Range = LastDays(days)
+		return options, nil
+	}
+
+	from, err := getDateFlag("from")
+	if err != nil {
+		return options, err
+	}
+	to, err := getDateFlag("to")
+	if err != nil {
+		return options, err
+	}
+	if !from.IsZero() || !to.IsZero() {
+		options.Range = BetweenDates(from, to)
+	}
+	return options, nil
+}
+
+func getTrendOptions() (TrendOptions, error) {
+	options := TrendOptions{Days: 90, Name: getFlag("name")}
+	if value := getFlag("days"); value != "" {
+		days, err := strconv.Atoi(value)
+		if err != nil {
+			days, err = ParseDays(value)
+			if err != nil {
+				return options, err
+			}
+		}
+		options.Days = days
+	}
+	return options, nil
+}
+
+func getResetScope() (ResetScope, error) {
+	from, err := getDateFlag("from")
+	if err != nil {
+		return ResetScope{}, err
+	}
+	to, err := getDateFlag("to")
+	if err != nil {
+		return ResetScope{}, err
+	}
+	return ResetScope{
+		Name:     getFlag("name"),
+		From:     from,
+		To:       to,
+		Finished: hasFlag("finished"),
+	}, nil
+}
+
+func lookupFlag(name string) (string, bool) {
+	flag := "--" + name
+	if len(name) == 1 {
+		flag = "-" + name
+	}
+	for i, arg := range os.Args {
+		if arg == flag && i+1 < len(os.Args) {
+			return os.Args[i+1], true
+		}
+		if strings.HasPrefix(arg, flag+"=") {
+			return strings.TrimPrefix(arg, flag+"="), true
+		}
+	}
+	return "", false
+}
+
+func getSessionEdit() (SessionEdit, error) {
+	var edit SessionEdit
+	if name, ok := lookupFlag("name"); ok {
+		edit.Name = &name
+	}
+	if start, ok := lookupFlag("start"); ok {
+		t, err := ParseDateTime(start)
+		if err != nil {
+			return edit, err
+		}
+		edit.Start = &t
+	}
+	if finish, ok := lookupFlag("finish"); ok {
+		t, err := ParseDateTime(finish)
+		if err != nil {
+			return edit, err
+		}
+		edit.Finish = &t
+	}
+	return edit, nil
+}
+
+func trashCommand(db *sql.DB) error {
+	switch getAdditionalOption() {
+	case "", "list":
+		return ListTrash(db)
+	case "restore":
+		var id int
+		if len(os.Args) > 3 {
+			id, _ = strconv.Atoi(os.Args[3])
+		}
+		if id == 0 {
+			return errors.New("session ID required")
+		}
+		return RestoreFromTrash(db, id)
+	case "empty":
+		var olderThan time.Duration
+		if value := getFlag("older-than"); value != "" {
+			days, err := ParseDays(value)
+			if err != nil {
+				return err
+			}
+			olderThan = time.Duration(days) * 24 * time.Hour
+		}
+		return EmptyTrash(db, olderThan)
+	}
+	return fmt.Errorf("unknown trash command '%s'", getAdditionalOption())
+}
+
 func DisplayUsage() {
-	fmt.Printf("clockin is a tool for recording work time.\n\nUsage:\n\n        clockin <command>\n\n        MySQL installation is required.\n\nThe commands are:\n\n        start          start timing a new work session\n        start <name>   start timing a new work session with an assigned name\n        finish         finish timing all currently running work sessions\n        finish <name>  finish timing a running work session, specified by its assigned name\n        running        list all currently running work sessions\n        stats          open statistics page\n        reset          delete all stored data\n")
+	fmt.Print(`clockin is a tool for recording work time.
+
+Usage:
+
+        clockin <command>
+
+        MySQL installation is required.
+
+The commands are:
+
+        start                     start timing a new work session
+        start <name>              start timing a new work session with an assigned name
+        finish                    finish timing all currently running work sessions
+        finish <name>             finish timing a running work session, specified by its assigned name
+        switch <name>             finish all running work sessions and start a new one
+        running                   list all currently running work sessions
+        stats                     open statistics page
+        stats <name>              open statistics page for the sessions with a name
+                                  options: --from <date> --to <date> --last <days, e.g. 90d>
+                                           --print --width <columns> --tab <title>
+        report                    print a summary of recorded time as a text table
+                                  options: --period today|week|month|year|all|custom --from <date> --to <date>
+                                           --group-by|--by name|day|week|hour --weekdays
+                                           --format text|html|markdown -o <file>
+                                           --template <file>
+        compare                   compare the time for each name with the period before
+                                  options: --period today|week|month|year|custom --from <date> --to <date>
+        timesheet                 print a grid of time per name for each day of a week
+                                  options: --week <week, e.g. 2026-W41> --format text|csv|markdown
+                                           --week-start monday|sunday
+        heatmap                   print the time recorded on each day over the last year
+                                  options: --week-start monday|sunday
+        trend                     print a graph of the hours recorded each day, with a 7-day average
+                                  options: --days <days, default 90> --name <name>
+        edit <id>                 change a session, keeping the old version in the trash
+                                  options: --name <name> --start <datetime> --finish <datetime>
+        delete <id>               move a session to the trash
+        history <id>              show every recorded change to a session
+        undo                      revert the last start, finish, switch, edit or delete
+        trash list                list deleted sessions and old versions of edited sessions
+        trash restore <id>        restore a session from the trash
+        trash empty               permanently delete sessions in the trash
+                                  options: --older-than <days, e.g. 30d>
+        import <file>             import completed sessions from an iCalendar (.ics) file
+                                  options: --match <text> --name <name> --from <date> --to <date>
+        backup [file]             save all sessions and config to a backup file
+        restore <file>            replace all sessions and config with a backup, or add missing sessions with --merge
+                                  options: --merge --yes
+        reset                     delete all stored data, after confirmation and writing a backup to the backups directory
+                                  options: --name <name> --from <date> --to <date> --finished --yes
+`)
 }
 
 func main() {
+	if err := SetupColors(); err != nil {
+		log.Printf("Loading colours failed with error: %s\n", err)
+	}
+
 	db, err := OpenDatabase()
 	if err != nil {
 		return
@@ -55,23 +318,182 @@ func main() {
 		}
 		RemindCurrentSessions(db)
 	case "reset":
-		err := Reset(db)
+		scope, err := getResetScope()
+		if err != nil {
+			log.Printf("Data reset failed with error: %s\n", err)
+			return
+		}
+		err = Reset(db, scope, hasFlag("yes"))
 		if err != nil {
 			log.Printf("Data reset failed with error: %s\n", err)
 			return
 		}
+	case "switch":
+		name := getAdditionalOption()
+		err := SwitchRecording(db, name)
+		if err != nil {
+			log.Printf("Switch recording failed with error: %s\n", err)
+			return
+		}
+		RemindCurrentSessions(db)
+	case "edit":
+		id, err := strconv.Atoi(getAdditionalOption())
+		if err != nil {
+			fmt.Println("clockin edit: session ID required")
+			return
+		}
+		edit, err := getSessionEdit()
+		if err != nil {
+			log.Printf("Edit failed with error: %s\n", err)
+			return
+		}
+		err = EditSession(db, id, edit)
+		if err != nil {
+			log.Printf("Edit failed with error: %s\n", err)
+			return
+		}
+	case "delete", "remove":
+		id, err := strconv.Atoi(getAdditionalOption())
+		if err != nil {
+			fmt.Println("clockin delete: session ID required")
+			return
+		}
+		err = DeleteSession(db, id)
+		if err != nil {
+			log.Printf("Delete failed with error: %s\n", err)
+			return
+		}
+	case "report":
+		options, err := getReportOptions()
+		if err != nil {
+			log.Printf("Report failed with error: %s\n", err)
+			return
+		}
+		err = Report(db, options)
+		if err != nil {
+			log.Printf("Report failed with error: %s\n", err)
+			return
+		}
+	case "timesheet":
+		options := TimesheetOptions{
+			Week:      getFlag("week"),
+			WeekStart: getFlag("week-start"),
+			Format:    getFlag("format"),
+		}
+		err := Timesheet(db, options)
+		if err != nil {
+			log.Printf("Timesheet failed with error: %s\n", err)
+			return
+		}
+	case "heatmap":
+		err := Heatmap(db, getFlag("week-start"))
+		if err != nil {
+			log.Printf("Heatmap failed with error: %s\n", err)
+			return
+		}
+	case "trend":
+		options, err := getTrendOptions()
+		if err != nil {
+			log.Printf("Trend failed with error: %s\n", err)
+			return
+		}
+		err = Trend(db, options)
+		if err != nil {
+			log.Printf("Trend failed with error: %s\n", err)
+			return
+		}
+	case "compare":
+		options, err := getReportOptions()
+		if err != nil {
+			log.Printf("Compare failed with error: %s\n", err)
+			return
+		}
+		err = Compare(db, options)
+		if err != nil {
+			log.Printf("Compare failed with error: %s\n", err)
+			return
+		}
+	case "history":
+		id, err := strconv.Atoi(getAdditionalOption())
+		if err != nil {
+			fmt.Println("clockin history: session ID required")
+			return
+		}
+		err = DisplayHistory(db, id)
+		if err != nil {
+			log.Printf("Display history failed with error: %s\n", err)
+			return
+		}
+	case "undo":
+		err := Undo(db)
+		if err != nil {
+			log.Printf("Undo failed with error: %s\n", err)
+			return
+		}
+	case "trash":
+		err := trashCommand(db)
+		if err != nil {
+			log.Printf("Trash %s failed with error: %s\n", getAdditionalOption(), err)
+			return
+		}
+	case "backup":
+		path := getAdditionalOption()
+		if path == "" {
+			path = DefaultBackupPath()
+		}
+		err := WriteBackup(db, path)
+		if err != nil {
+			log.Printf("Backup failed with error: %s\n", err)
+			return
+		}
+	case "restore":
+		path := getAdditionalOption()
+		if path == "" {
+			fmt.Println("clockin restore: backup file required")
+			return
+		}
+		err := RestoreBackup(db, path, hasFlag("merge"), hasFlag("yes"))
+		if err != nil {
+			log.Printf("Restore failed with error: %s\n", err)
+			return
+		}
 	case "status", "info", "running":
 		err := DisplayStatus(db)
 		if err != nil {
-			log.Printf("Data reset failed with error: %s\n", err)
+			log.Printf("Display status failed with error: %s\n", err)
 			return
 		}
 	case "stats", "statistics":
-		err := DisplayStats(db)
+		options, err := getStatsOptions()
+		if err != nil {
+			log.Printf("Display stats failed with error: %s\n", err)
+			return
+		}
+		if hasFlag("print") {
+			err = PrintStats(db, options)
+		} else {
+			err = DisplayStats(db, options)
+		}
 		if err != nil {
 			log.Printf("Display stats failed with error: %s\n", err)
 			return
 		}
+	case "import":
+		path := getAdditionalOption()
+		if path == "" {
+			fmt.Println("clockin import: calendar file required")
+			return
+		}
+		from, to, err := getDateWindow()
+		if err != nil {
+			log.Printf("Import failed with error: %s\n", err)
+			return
+		}
+		err = ImportCalendar(db, path, getFlag("match"), getFlag("name"), from, to)
+		if err != nil {
+			log.Printf("Import failed with error: %s\n", err)
+			return
+		}
 	case "", "help":
 		DisplayUsage()
 	case "show":
@@ -9,10 +9,10 @@ require (
 	github.com/guptarohit/asciigraph v0.5.5
 	github.com/hako/durafmt v0.0.0-20210608085754-5c1018a4e16b
 	github.com/joho/godotenv v1.4.0
+	github.com/mattn/go-runewidth v0.0.14
 )
 
 require (
-	github.com/mattn/go-runewidth v0.0.14 // indirect
 	github.com/mitchellh/go-wordwrap v1.0.1 // indirect
 	github.com/nsf/termbox-go v1.1.1 // indirect
 	github.com/rivo/uniseg v0.4.2 // indirect
@@ -0,0 +1,156 @@
+package clockin
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TwiN/go-color"
+)
+
+// execer is satisfied by both *sql.DB and *sql.Tx, so changes made within a
+// transaction can be audited as part of it.
+type execer interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+}
+
+func auditValues(session Session) interface{} {
+	if session.ID == 0 {
+		return nil
+	}
+	values, err := json.Marshal(toBackupSession(session))
+	if err != nil {
+		return nil
+	}
+	return string(values)
+}
+
+// auditChange records a change to a session made by the given source command.
+// A zero Session before or after the change represents a session that did not
+// exist at that point.
+func auditChange(ex execer, source string, action string, before Session, after Session) error {
+	id := after.ID
+	if id == 0 {
+		id = before.ID
+	}
+	_, err := ex.Exec("INSERT INTO clockin_audit(session_id, action, old_values, new_values, source) VALUES (?, ?, ?, ?, ?)",
+		id, action, auditValues(before), auditValues(after), source)
+	if err != nil {
+		log.Printf("Error when recording audit history: %s\n", err)
+		return err
+	}
+	return nil
+}
+
+// sessionState returns a session by ID whether or not it is in the trash, or
+// a zero Session if it no longer exists.
+func sessionState(db *sql.DB, id int) (Session, error) {
+	sessions, err := querySessions(db, "id=?", id)
+	if err != nil || len(sessions) == 0 {
+		return Session{}, err
+	}
+	return sessions[0], nil
+}
+
+type auditRecord struct {
+	action  string
+	source  string
+	created time.Time
+	before  *BackupSession
+	after   *BackupSession
+}
+
+func parseAuditValues(values sql.NullString) *BackupSession {
+	if !values.Valid {
+		return nil
+	}
+	var session BackupSession
+	if err := json.Unmarshal([]byte(values.String), &session); err != nil {
+		return nil
+	}
+	return &session
+}
+
+func finishValue(finish *string) string {
+	if finish == nil {
+		return "running"
+	}
+	return *finish
+}
+
+func describeValues(session *BackupSession) string {
+	return fmt.Sprintf("name: %s, start: %s, finish: %s", displayName(session.Name),
+		session.Start, finishValue(session.Finish))
+}
+
+func describeChange(record auditRecord) string {
+	switch {
+	case record.before == nil && record.after == nil:
+		return ""
+	case record.before == nil:
+		return describeValues(record.after)
+	case record.after == nil:
+		return describeValues(record.before)
+	}
+
+	changes := ""
+	addChange := func(field string, before string, after string) {
+		if before != after {
+			if changes != "" {
+				changes += ", "
+			}
+			changes += fmt.Sprintf("%s: %s -> %s", field, before, after)
+		}
+	}
+	addChange("name", displayName(record.before.Name), displayName(record.after.Name))
+	addChange("start", record.before.Start, record.after.Start)
+	addChange("finish", finishValue(record.before.Finish), finishValue(record.after.Finish))
+	if changes == "" {
+		return "no changes"
+	}
+	return changes
+}
+
+// DisplayHistory prints every recorded change to a session, oldest first.
+func DisplayHistory(db *sql.DB, id int) error {
+	rows, err := db.Query("SELECT action, source, created, old_values, new_values FROM clockin_audit WHERE session_id=? ORDER BY created, id", id)
+	if err != nil {
+		log.Printf("Error when reading audit history: %s\n", err)
+		return err
+	}
+	defer rows.Close()
+
+	records := []auditRecord{}
+	for rows.Next() {
+		var record auditRecord
+		var before, after sql.NullString
+		err = rows.Scan(&record.action, &record.source, &record.created, &before, &after)
+		if err != nil {
+			return err
+		}
+		record.before = parseAuditValues(before)
+		record.after = parseAuditValues(after)
+		records = append(records, record)
+	}
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(records) == 0 {
+		fmt.Printf(color.Ize(color.Yellow, "No history recorded for session %d\n"), id)
+		return nil
+	}
+
+	fmt.Printf(color.Ize(color.Green, "History of session [%d]:\n"), id)
+	for _, record := range records {
+		fmt.Printf("%s  %s  %s\n", record.created.Format("2006-01-02 15:04:05"),
+			color.Ize(color.Yellow, fmt.Sprintf("%-8s", record.action)),
+			color.Ize(color.Blue, fmt.Sprintf("%-14s", "("+record.source+")")))
+		if description := describeChange(record); description != "" {
+			fmt.Printf("        %s\n", description)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,334 @@
+package clockin
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/TwiN/go-color"
+)
+
+const (
+	backupFormat        = "clockin-backup"
+	backupSchemaVersion = 1
+	backupDir           = "backups"
+	backupTimeLayout    = "2006-01-02 15:04:05"
+)
+
+type Backup struct {
+	Format        string          `json:"format"`
+	SchemaVersion int             `json:"schemaVersion"`
+	Created       string          `json:"created"`
+	Sessions      []BackupSession `json:"sessions"`
+	Config        json.RawMessage `json:"config,omitempty"`
+}
+
+// BackupSession holds a session with its times stored as local wall clock
+// strings, with a null finish for sessions that were still running.
+type BackupSession struct {
+	ID     int     `json:"id"`
+	Name   string  `json:"name"`
+	Start  string  `json:"start"`
+	Finish *string `json:"finish"`
+}
+
+func toBackupSession(session Session) BackupSession {
+	s := BackupSession{
+		ID:    session.ID,
+		Name:  session.Name,
+		Start: session.Start.Format(backupTimeLayout),
+	}
+	if !session.Finish.IsZero() {
+		finish := session.Finish.Format(backupTimeLayout)
+		s.Finish = &finish
+	}
+	return s
+}
+
+func (s BackupSession) toSession() (Session, error) {
+	session := Session{ID: s.ID, Name: s.Name}
+	start, err := time.Parse(backupTimeLayout, s.Start)
+	if err != nil {
+		return session, fmt.Errorf("session %d has invalid start '%s'", s.ID, s.Start)
+	}
+	session.Start = start
+	if s.Finish != nil {
+		finish, err := time.Parse(backupTimeLayout, *s.Finish)
+		if err != nil {
+			return session, fmt.Errorf("session %d has invalid finish '%s'", s.ID, *s.Finish)
+		}
+		if finish.Before(start) {
+			return session, fmt.Errorf("session %d finishes before it starts", s.ID)
+		}
+		session.Finish = finish
+	}
+	if len(session.Name) > 100 {
+		return session, fmt.Errorf("session %d name is longer than 100 characters", s.ID)
+	}
+	return session, nil
+}
+
+func readConfigFile() (json.RawMessage, error) {
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if !json.Valid(data) {
+		return nil, fmt.Errorf("%s is not valid JSON", configPath)
+	}
+	return data, nil
+}
+
+func createBackup(db *sql.DB) (Backup, error) {
+	sessions, err := getSessions(db, true, "")
+	if err != nil {
+		return Backup{}, err
+	}
+
+	config, err := readConfigFile()
+	if err != nil {
+		return Backup{}, err
+	}
+
+	backup := Backup{
+		Format:        backupFormat,
+		SchemaVersion: backupSchemaVersion,
+		Created:       CurrentTime().Format(backupTimeLayout),
+		Sessions:      make([]BackupSession, len(sessions)),
+		Config:        config,
+	}
+	for i, session := range sessions {
+		backup.Sessions[i] = toBackupSession(session)
+	}
+	return backup, nil
+}
+
+func DefaultBackupPath() string {
+	return fmt.Sprintf("clockin-backup-%s.json", CurrentTime().Format("20060102-150405"))
+}
+
+func writeBackup(db *sql.DB, path string) (int, error) {
+	backup, err := createBackup(db)
+	if err != nil {
+		log.Printf("Error when reading data to back up: %s\n", err)
+		return 0, err
+	}
+
+	data, err := json.MarshalIndent(backup, "", "  ")
+	if err != nil {
+		return 0, err
+	}
+
+	if dir := filepath.Dir(path); dir != "." {
+		err = os.MkdirAll(dir, 0755)
+		if err != nil {
+			return 0, err
+		}
+	}
+	err = os.WriteFile(path, data, 0644)
+	if err != nil {
+		log.Printf("Error when writing backup file: %s\n", err)
+		return 0, err
+	}
+	return len(backup.Sessions), nil
+}
+
+func WriteBackup(db *sql.DB, path string) error {
+	n, err := writeBackup(db, path)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Backed up %d sessions to %s\n"), n, path)
+	return nil
+}
+
+// autoBackup writes a backup into the backups directory before data is
+// removed, returning the path of the backup file.
+func autoBackup(db *sql.DB) (string, error) {
+	path := filepath.Join(backupDir, DefaultBackupPath())
+	_, err := writeBackup(db, path)
+	return path, err
+}
+
+func readBackup(path string) (Backup, []Session, error) {
+	var backup Backup
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return backup, nil, err
+	}
+
+	err = json.Unmarshal(data, &backup)
+	if err != nil {
+		return backup, nil, fmt.Errorf("%s is not a valid backup file: %w", path, err)
+	}
+	if backup.Format != backupFormat {
+		return backup, nil, fmt.Errorf("%s is not a clockin backup", path)
+	}
+	if backup.SchemaVersion < 1 || backup.SchemaVersion > backupSchemaVersion {
+		return backup, nil, fmt.Errorf("unsupported backup schema version %d", backup.SchemaVersion)
+	}
+	if len(backup.Config) > 0 && !json.Valid(backup.Config) {
+		return backup, nil, errors.New("backup config is not valid JSON")
+	}
+
+	sessions := make([]Session, len(backup.Sessions))
+	ids := make(map[int]bool)
+	for i, s := range backup.Sessions {
+		if ids[s.ID] {
+			return backup, nil, fmt.Errorf("session %d appears more than once", s.ID)
+		}
+		ids[s.ID] = true
+		sessions[i], err = s.toSession()
+		if err != nil {
+			return backup, nil, err
+		}
+	}
+	return backup, sessions, nil
+}
+
+func replaceSessions(db *sql.DB, sessions []Session) error {
+	existing, err := querySessions(db, "deleted_at IS NULL")
+	if err != nil {
+		return err
+	}
+	replaced := make(map[int]Session)
+	for _, session := range existing {
+		replaced[session.ID] = session
+	}
+
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancelfunc()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// The trash and old versions of sessions are not part of backups, so
+	// they are kept
+	_, err = tx.ExecContext(ctx, "DELETE FROM clockin WHERE deleted_at IS NULL")
+	if err != nil {
+		log.Printf("Error when clearing sessions: %s\n", err)
+		return err
+	}
+	// Commands made before the restore no longer apply to the sessions
+	_, err = tx.ExecContext(ctx, "UPDATE clockin_undo SET undone=true WHERE undone=false")
+	if err != nil {
+		log.Printf("Error when discarding commands to undo: %s\n", err)
+		return err
+	}
+	for _, session := range sessions {
+		// Anything in the trash with the same ID gives way to the restored session
+		_, err = tx.ExecContext(ctx, "DELETE FROM clockin WHERE id=?", session.ID)
+		if err != nil {
+			log.Printf("Error when restoring session: %s\n", err)
+			return err
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO clockin(id, name, start, finish) VALUES (?, ?, ?, ?)",
+			session.ID, session.Name, session.Start, nullTime(session.Finish))
+		if err != nil {
+			log.Printf("Error when restoring session: %s\n", err)
+			return err
+		}
+		err = auditChange(tx, "restore", "restore", replaced[session.ID], session)
+		if err != nil {
+			return err
+		}
+		delete(replaced, session.ID)
+	}
+	// Sessions that were not in the backup have been removed
+	for _, session := range replaced {
+		err = auditChange(tx, "restore", "delete", session, Session{})
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func mergeSessions(db *sql.DB, sessions []Session) (int, error) {
+	added := 0
+	for _, session := range sessions {
+		exists, err := sessionExists(db, session)
+		if err != nil {
+			return added, err
+		}
+		if exists {
+			continue
+		}
+		_, err = insertSession(db, session, "restore")
+		if err != nil {
+			return added, err
+		}
+		added++
+	}
+	return added, nil
+}
+
+// RestoreBackup validates a backup file and loads its sessions. By default
+// all existing sessions and the config are replaced by the backup, after
+// asking for confirmation unless already confirmed and writing a backup of
+// the current data. When merging, only sessions that are not already
+// recorded are added.
+func RestoreBackup(db *sql.DB, path string, merge bool, confirmed bool) error {
+	backup, sessions, err := readBackup(path)
+	if err != nil {
+		log.Printf("Error when reading backup: %s\n", err)
+		return err
+	}
+
+	if merge {
+		added, err := mergeSessions(db, sessions)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(color.Ize(color.Green, "Merged %d of %d sessions from %s\n"), added, len(sessions), path)
+		return nil
+	}
+
+	existing, err := querySessions(db, "deleted_at IS NULL")
+	if err != nil {
+		log.Printf("Error when finding sessions to replace: %s\n", err)
+		return err
+	}
+	if len(existing) > 0 {
+		fmt.Printf(color.Ize(color.Yellow, "%d sessions (%s recorded) and the config will be replaced by %d sessions from %s\n"),
+			len(existing), formatDuration(totalDuration(existing), 2), len(sessions), path)
+		if !confirmed && !confirm("restore") {
+			fmt.Println(color.Ize(color.Red, "Restore cancelled"))
+			return nil
+		}
+	}
+
+	backupPath, err := autoBackup(db)
+	if err != nil {
+		log.Printf("Error when backing up data before restore: %s\n", err)
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Backup written to %s\n"), backupPath)
+
+	err = replaceSessions(db, sessions)
+	if err != nil {
+		return err
+	}
+	if len(backup.Config) > 0 {
+		var config bytes.Buffer
+		json.Indent(&config, backup.Config, "", "    ")
+		err = os.WriteFile(configPath, config.Bytes(), 0644)
+		if err != nil {
+			log.Printf("Error when restoring config: %s\n", err)
+			return err
+		}
+	}
+	fmt.Printf(color.Ize(color.Green, "Restored %d sessions from %s\n"), len(sessions), path)
+	return nil
+}
@@ -0,0 +1,229 @@
+package clockin
+
+import (
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// Number of sessions the session browser loads from the database at a time
+const browserWindow = 500
+
+// Expressions sorting the session browser by each column of a session table,
+// where ? is the current time for running sessions
+var sessionSortKeys = []string{
+	"id",
+	"LOWER(name)",
+	"start",
+	"COALESCE(finish, ?)",
+	"TIMESTAMPDIFF(SECOND, start, COALESCE(finish, ?))",
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`)
+
+// sessionFilter restricts the sessions in the session browser to names
+// containing some text, or to sessions started on one day.
+type sessionFilter struct {
+	text string
+	name string
+	day  time.Time
+}
+
+// parseSessionFilter reads a search entered in the Sessions tab. A date such
+// as 2026-09-01 matches the sessions started that day, and anything else
+// matches names containing it, ignoring case.
+func parseSessionFilter(input string) sessionFilter {
+	input = strings.TrimSpace(input)
+	if input == "" {
+		return sessionFilter{}
+	}
+	if day, err := ParseDate(input); err == nil {
+		return sessionFilter{text: input, day: day}
+	}
+	return sessionFilter{text: input, name: input}
+}
+
+func (f sessionFilter) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if f.name != "" {
+		conditions = append(conditions, "LOWER(name) LIKE ?")
+		args = append(args, "%"+likeEscaper.Replace(strings.ToLower(f.name))+"%")
+	}
+	if !f.day.IsZero() {
+		conditions = append(conditions, "start >= ? AND start < ?")
+		args = append(args, f.day, f.day.AddDate(0, 0, 1))
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// sessionOrder returns an ORDER BY clause matching the in-memory sort of a
+// session table, with ties broken by ID.
+func sessionOrder(column int, descending bool, now time.Time) (string, []interface{}) {
+	direction := ""
+	if descending {
+		direction = " DESC"
+	}
+	key := sessionSortKeys[column]
+	args := []interface{}{}
+	for i := 0; i < strings.Count(key, "?"); i++ {
+		args = append(args, now)
+	}
+	if column == 0 {
+		return key + direction, args
+	}
+	return key + direction + ", id" + direction, args
+}
+
+// sessionBrowser is a session table over every session, loading a window of
+// sessions around the selected one so that scrolling through thousands of
+// sessions does not load them all at once.
+type sessionBrowser struct {
+	*sessionTable
+	db     *sql.DB
+	filter sessionFilter
+	// Name the page's filter restricts the browser to, on top of the
+	// search filter, copied from Page.name
+	name string
+	// Number of sessions matching the filter
+	total int
+	// Index among the matching sessions of the first loaded session
+	start int
+}
+
+func newSessionBrowser(db *sql.DB) *sessionBrowser {
+	return &sessionBrowser{
+		sessionTable: newSessionTable([]Session{}, 2, true),
+		db:           db,
+	}
+}
+
+// load fetches the window of sessions beginning at start.
+func (b *sessionBrowser) load() error {
+	where, args := b.filter.where()
+	if b.name != "" {
+		where += " AND name = ?"
+		args = append(args, b.name)
+	}
+	err := b.db.QueryRow("SELECT COUNT(*) FROM clockin WHERE "+where, args...).Scan(&b.total)
+	if err != nil {
+		return err
+	}
+	if b.start > b.total-browserWindow {
+		b.start = b.total - browserWindow
+	}
+	if b.start < 0 {
+		b.start = 0
+	}
+
+	order, orderArgs := sessionOrder(b.sortColumn, b.descending, CurrentTime())
+	args = append(args, orderArgs...)
+	args = append(args, browserWindow, b.start)
+	sessions, err := querySessions(b.db, where+" ORDER BY "+order+" LIMIT ? OFFSET ?", args...)
+	if err != nil {
+		return err
+	}
+	b.sessions = sessions
+	return nil
+}
+
+// reload fetches the loaded window again, keeping the same session selected
+// if it is still there.
+func (b *sessionBrowser) reload() error {
+	selected, ok := b.selectedSession()
+	if err := b.load(); err != nil {
+		return err
+	}
+	if ok {
+		for i, session := range b.sessions {
+			if session.ID == selected.ID {
+				b.selected = i
+			}
+		}
+	}
+	b.sessionTable.move(0)
+	b.update()
+	return nil
+}
+
+// move changes the selected session by delta rows, loading another window
+// when it moves outside the loaded one.
+func (b *sessionBrowser) move(delta int) error {
+	if b.total == 0 {
+		return nil
+	}
+	target := b.start + b.selected + delta
+	if target < 0 {
+		target = 0
+	} else if target >= b.total {
+		target = b.total - 1
+	}
+	if target < b.start || target >= b.start+len(b.sessions) {
+		// Keep the selected row at the same height in the table
+		row := b.selected - b.offset
+		b.start = target - browserWindow/2
+		if err := b.load(); err != nil {
+			return err
+		}
+		b.offset = target - b.start - row
+		if b.offset < 0 {
+			b.offset = 0
+		}
+	}
+	b.selected = target - b.start
+	b.sessionTable.move(0)
+	return nil
+}
+
+// reset loads the first window of sessions, such as after the sort order or
+// filter changes.
+func (b *sessionBrowser) reset() error {
+	b.start = 0
+	b.selected = 0
+	b.offset = 0
+	if err := b.load(); err != nil {
+		return err
+	}
+	b.update()
+	return nil
+}
+
+func (b *sessionBrowser) sortBy(column int, descending bool) error {
+	b.sortColumn = column
+	b.descending = descending
+	return b.reset()
+}
+
+func (b *sessionBrowser) nextSortColumn() error {
+	return b.sortBy((b.sortColumn+1)%len(sessionTableHeaders), b.descending)
+}
+
+func (b *sessionBrowser) reverseSort() error {
+	return b.sortBy(b.sortColumn, !b.descending)
+}
+
+func (b *sessionBrowser) search(filter sessionFilter) error {
+	b.filter = filter
+	return b.reset()
+}
+
+// Draw shows which of the matching sessions are in view in the title.
+func (b *sessionBrowser) Draw(buf *ui.Buffer) {
+	b.scrollToSelected()
+	b.Title = "Sessions"
+	if b.total > 0 {
+		first := b.start + b.offset + 1
+		last := first + b.visibleRows() - 1
+		if last > b.start+len(b.sessions) {
+			last = b.start + len(b.sessions)
+		}
+		b.Title += fmt.Sprintf(" %d-%d of %d", first, last, b.total)
+	}
+	if b.filter.text != "" {
+		b.Title += " matching " + b.filter.text
+	}
+	b.sessionTable.Draw(buf)
+}
@@ -0,0 +1,105 @@
+package clockin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// previousPeriod returns the period of the same length that ends at start.
+func previousPeriod(start time.Time, end time.Time) (time.Time, time.Time) {
+	return start.Add(-end.Sub(start)), start
+}
+
+// formatChange describes the difference from previous to current with an up
+// or down arrow.
+func formatChange(current time.Duration, previous time.Duration) string {
+	if current > previous {
+		return "▲ " + formatHours(current-previous)
+	} else if current < previous {
+		return "▼ " + formatHours(previous-current)
+	}
+	return "= " + formatHours(0)
+}
+
+func formatChangePercentage(current time.Duration, previous time.Duration) string {
+	if previous == 0 {
+		if current == 0 {
+			return "0.0%"
+		}
+		return "new"
+	}
+	return fmt.Sprintf("%+.1f%%", 100*float64(current-previous)/float64(previous))
+}
+
+// changeMarkup formats a change for the stats page, in the theme's colours
+// for more and less time.
+func changeMarkup(current time.Duration, previous time.Duration) string {
+	text := formatChange(current, previous)
+	if current > previous {
+		return "[" + text + "](fg:increase)"
+	} else if current < previous {
+		return "[" + text + "](fg:decrease)"
+	}
+	return text
+}
+
+func buildCompareTable(current []Session, previous []Session) textTable {
+	table := textTable{
+		headers:    []string{"Name", "Previous", "Current", "Change", ""},
+		alignRight: []bool{false, true, true, true, true},
+	}
+
+	currentTime := nameDurations(current)
+	previousTime := nameDurations(previous)
+	// Names recorded in the current period come first, followed by names
+	// only recorded in the previous period
+	names := sortedNames(currentTime)
+	for _, name := range sortedNames(previousTime) {
+		if _, ok := currentTime[name]; !ok {
+			names = append(names, name)
+		}
+	}
+
+	for _, name := range names {
+		table.addRow(displayName(name), formatHours(previousTime[name]), formatHours(currentTime[name]),
+			formatChange(currentTime[name], previousTime[name]),
+			formatChangePercentage(currentTime[name], previousTime[name]))
+	}
+	table.addSeparator()
+	currentTotal := totalDuration(current)
+	previousTotal := totalDuration(previous)
+	table.addRow("Total", formatHours(previousTotal), formatHours(currentTotal),
+		formatChange(currentTotal, previousTotal), formatChangePercentage(currentTotal, previousTotal))
+	return table
+}
+
+// Compare prints the time recorded for each name within a period next to the
+// time recorded in the period of the same length before it. Only the Period,
+// From and To options are used.
+func Compare(db *sql.DB, options ReportOptions) error {
+	if options.Period == "all" {
+		return errors.New("all time has no previous period to compare with")
+	}
+	start, end, description, err := reportRange(options)
+	if err != nil {
+		return err
+	}
+	previousStart, previousEnd := previousPeriod(start, end)
+
+	current, err := reportSessions(db, start, end)
+	if err != nil {
+		return err
+	}
+	previous, err := reportSessions(db, previousStart, previousEnd)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Comparing %s with %s to %s\n\n", description,
+		previousStart.Format("2006-01-02 15:04"), previousEnd.Format("2006-01-02 15:04"))
+	buildCompareTable(current, previous).render(os.Stdout)
+	return nil
+}
@@ -0,0 +1,72 @@
+package clockin
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+)
+
+const configPath = "config.json"
+
+// Config holds the settings read from config.json. Settings missing from the
+// file keep their default values.
+type Config struct {
+	Timeout          *int   `json:"timeout"`
+	DiscardOnTimeout bool   `json:"discardOnTimeout"`
+	WeekStart        string `json:"weekStart"`
+	ReportTemplate   string `json:"reportTemplate"`
+	StreakMinimum    int    `json:"streakMinimum"`
+	Theme            string `json:"theme"`
+	// Colours pinned to session names, by colour name or 256-colour code
+	NameColors map[string]string `json:"nameColors"`
+	// Keys of the stats page, a preset changed by the keys of any actions
+	Keymap string              `json:"keymap"`
+	Keys   map[string][]string `json:"keys"`
+}
+
+func defaultConfig() Config {
+	return Config{WeekStart: "monday", StreakMinimum: 30}
+}
+
+func LoadConfig() (Config, error) {
+	config := defaultConfig()
+	data, err := os.ReadFile(configPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return config, nil
+	} else if err != nil {
+		return config, err
+	}
+
+	err = json.Unmarshal(data, &config)
+	if err != nil {
+		return config, fmt.Errorf("invalid %s: %w", configPath, err)
+	}
+	return config, nil
+}
+
+// parseWeekStart returns the first day of the week from a weekStart setting.
+func parseWeekStart(weekStart string) (time.Weekday, error) {
+	switch strings.ToLower(weekStart) {
+	case "", "monday", "mon":
+		return time.Monday, nil
+	case "sunday", "sun":
+		return time.Sunday, nil
+	}
+	return time.Monday, fmt.Errorf("week start must be monday or sunday, not '%s'", weekStart)
+}
+
+// loadWeekStart returns the first day of the week given by setting, or by the
+// weekStart setting in the config if setting is empty.
+func loadWeekStart(setting string) (time.Weekday, error) {
+	if setting == "" {
+		config, err := LoadConfig()
+		if err != nil {
+			return time.Monday, err
+		}
+		setting = config.WeekStart
+	}
+	return parseWeekStart(setting)
+}
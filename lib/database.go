@@ -3,9 +3,12 @@ package clockin
 import (
 	"context"
 	"database/sql"
+	"errors"
 	"fmt"
 	"log"
 	"os"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/TwiN/go-color"
@@ -17,6 +20,9 @@ const (
 	dbname   = "clockin"
 )
 
+// Columns scanned into a Session, in the order expected by ExtractSessions
+const sessionColumns = "id, name, start, finish"
+
 func getDBLoginDetails() (string, string, bool) {
 	godotenv.Load(".env")
 	fromEnv := true
@@ -63,7 +69,7 @@ func DisplayStatus(db *sql.DB) error {
 			printCurrentSession(session)
 		}
 	}
-	return nil
+	return printStreaks(db)
 }
 
 func dsn(username string, password string, dbName string) string {
@@ -107,25 +113,53 @@ func dbConnection(username string, password string) (*sql.DB, error) {
 	return db, nil
 }
 
+func addColumnIfMissing(ctx context.Context, db *sql.DB, table string, column string, definition string) error {
+	var count int
+	err := db.QueryRowContext(ctx, `SELECT COUNT(*) FROM information_schema.COLUMNS
+		WHERE TABLE_SCHEMA=DATABASE() AND TABLE_NAME=? AND COLUMN_NAME=?`, table, column).Scan(&count)
+	if err != nil || count > 0 {
+		return err
+	}
+	_, err = db.ExecContext(ctx, fmt.Sprintf("ALTER TABLE %s ADD COLUMN %s %s", table, column, definition))
+	return err
+}
+
 func createTable(db *sql.DB) error {
-	query := `CREATE TABLE IF NOT EXISTS clockin(id int primary key auto_increment, name varchar(100), start datetime default CURRENT_TIMESTAMP, finish datetime)`
+	queries := []string{
+		`CREATE TABLE IF NOT EXISTS clockin(id int primary key auto_increment, name varchar(100), start datetime default CURRENT_TIMESTAMP, finish datetime, deleted_at datetime, version_of int)`,
+		`CREATE TABLE IF NOT EXISTS clockin_undo(id int primary key auto_increment, op bigint, command varchar(20), action varchar(20), session_id int, version_id int, created datetime default CURRENT_TIMESTAMP, undone boolean default false)`,
+		`CREATE TABLE IF NOT EXISTS clockin_audit(id int primary key auto_increment, session_id int, action varchar(20), old_values text, new_values text, source varchar(20), created datetime default CURRENT_TIMESTAMP, index(session_id))`,
+	}
 	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelfunc()
 
-	_, err := db.ExecContext(ctx, query)
-	if err != nil {
-		log.Printf("Error when creating table: %s\n", err)
-		return err
+	for _, query := range queries {
+		_, err := db.ExecContext(ctx, query)
+		if err != nil {
+			log.Printf("Error when creating table: %s\n", err)
+			return err
+		}
+	}
+
+	// Columns added since the clockin table was first released
+	columns := [][]string{{"deleted_at", "datetime"}, {"version_of", "int"}}
+	for _, column := range columns {
+		err := addColumnIfMissing(ctx, db, "clockin", column[0], column[1])
+		if err != nil {
+			log.Printf("Error when adding column %s: %s\n", column[0], err)
+			return err
+		}
 	}
 
 	return nil
 }
 
 func ShowTable(db *sql.DB) error {
-	res, err := db.Query("SELECT * FROM clockin")
+	res, err := db.Query("SELECT " + sessionColumns + " FROM clockin WHERE deleted_at IS NULL")
 	if err != nil {
 		return err
 	}
+	defer res.Close()
 
 	for res.Next() {
 		var session Session
@@ -144,21 +178,27 @@ func ShowTable(db *sql.DB) error {
 	return nil
 }
 
-func StartRecording(db *sql.DB, name string) error {
+func startRecording(db *sql.DB, name string) (int, error) {
 	query := "INSERT INTO clockin(name, start, finish) VALUES (?, NOW(), NULL)"
 	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancelfunc()
 	stmt, err := db.PrepareContext(ctx, query)
 	if err != nil {
 		log.Printf("Error when preparing SQL insert statement: %s\n", err)
-		return err
+		return 0, err
 	}
 	defer stmt.Close()
 
-	_, err = stmt.ExecContext(ctx, name)
+	res, err := stmt.ExecContext(ctx, name)
 	if err != nil {
 		log.Printf("Error when inserting row into products table: %s\n", err)
-		return err
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		log.Printf("Error when getting inserted session ID: %s\n", err)
+		return 0, err
 	}
 
 	now := time.Now().Format("2006-01-02 15:04:05")
@@ -167,9 +207,89 @@ func StartRecording(db *sql.DB, name string) error {
 	} else {
 		fmt.Printf(color.Ize(color.Green, "Started recording %s (%s)\n"), name, now)
 	}
+	return int(id), nil
+}
+
+// auditStart records the creation of a newly started session.
+func auditStart(db *sql.DB, source string, id int) error {
+	session, err := sessionState(db, id)
+	if err != nil {
+		return err
+	}
+	return auditChange(db, source, "start", Session{}, session)
+}
+
+// auditFinish records the finish time of each session that was stopped.
+func auditFinish(db *sql.DB, source string, stopped []Session) error {
+	for _, session := range stopped {
+		finished, err := sessionState(db, session.ID)
+		if err != nil {
+			return err
+		}
+		err = auditChange(db, source, "finish", session, finished)
+		if err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
+func StartRecording(db *sql.DB, name string) error {
+	id, err := startRecording(db, name)
+	if err != nil {
+		return err
+	}
+	err = auditStart(db, "start", id)
+	if err != nil {
+		return err
+	}
+	return recordUndo(db, "start", []undoEntry{{action: "start", sessionID: id}})
+}
+
+// insertSession records a session imported from elsewhere, such as a
+// calendar or backup, returning its new ID.
+func insertSession(db *sql.DB, session Session, source string) (int, error) {
+	query := "INSERT INTO clockin(name, start, finish) VALUES (?, ?, ?)"
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelfunc()
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		log.Printf("Error when preparing SQL insert statement: %s\n", err)
+		return 0, err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, session.Name, session.Start, nullTime(session.Finish))
+	if err != nil {
+		log.Printf("Error when inserting session: %s\n", err)
+		return 0, err
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return 0, err
+	}
+	session.ID = int(id)
+	return session.ID, auditChange(db, source, "import", Session{}, session)
+}
+
+func sessionExists(db *sql.DB, session Session) (bool, error) {
+	var count int
+	var err error
+	if session.Finish.IsZero() {
+		err = db.QueryRow("SELECT COUNT(*) FROM clockin WHERE name=? AND start=? AND finish IS NULL AND deleted_at IS NULL",
+			session.Name, session.Start).Scan(&count)
+	} else {
+		err = db.QueryRow("SELECT COUNT(*) FROM clockin WHERE name=? AND start=? AND finish=? AND deleted_at IS NULL",
+			session.Name, session.Start, session.Finish).Scan(&count)
+	}
+	if err != nil {
+		log.Printf("Error when checking for existing session: %s\n", err)
+		return false, err
+	}
+	return count > 0, nil
+}
+
 func sessionInList(session Session, sessions []Session) bool {
 	for _, s := range sessions {
 		if s.ID == session.ID {
@@ -198,15 +318,15 @@ func getUpdatedSession(activeBefore []Session, activeAfter []Session) Session {
 	return Session{}
 }
 
-func FinishRecording(db *sql.DB, name string) error {
+func finishRecording(db *sql.DB, name string) ([]Session, error) {
 	activeSessionsBefore, err := currentSessions(db)
 	Check(err)
 
 	var query string
 	if name == "" {
-		query = "UPDATE clockin set finish=NOW() WHERE finish is NULL"
+		query = "UPDATE clockin set finish=NOW() WHERE finish is NULL AND deleted_at IS NULL"
 	} else {
-		query = "UPDATE clockin set finish=NOW() WHERE finish is NULL AND name=?"
+		query = "UPDATE clockin set finish=NOW() WHERE finish is NULL AND deleted_at IS NULL AND name=?"
 	}
 
 	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
@@ -214,7 +334,7 @@ func FinishRecording(db *sql.DB, name string) error {
 	stmt, err := db.PrepareContext(ctx, query)
 	if err != nil {
 		log.Printf("Error when preparing SQL update statement: %s\n", err)
-		return err
+		return nil, err
 	}
 	defer stmt.Close()
 
@@ -226,15 +346,19 @@ func FinishRecording(db *sql.DB, name string) error {
 	}
 	if err != nil {
 		log.Printf("Error when inserting row into products table: %s\n", err)
-		return err
+		return nil, err
 	}
 
 	n, err := rowsAffected(res)
 	if err != nil {
 		log.Printf("Error when finding rows affected: %s\n", err)
-		return err
+		return nil, err
 	}
 
+	activeSessionsAfter, err := currentSessions(db)
+	Check(err)
+	stopped := getUpdatedSessions(activeSessionsBefore, activeSessionsAfter)
+
 	now := CurrentTime()
 	if name == "" {
 		if n == 0 {
@@ -242,8 +366,6 @@ func FinishRecording(db *sql.DB, name string) error {
 		} else if n > 1 {
 			fmt.Printf(color.Ize(color.Green, "Stopped recording for %d sessions\n"), n)
 		} else {
-			activeSessionsAfter, err := currentSessions(db)
-			Check(err)
 			updated := getUpdatedSession(activeSessionsBefore, activeSessionsAfter)
 			updated.Finish = now
 			duration := calcDuration(updated)
@@ -256,8 +378,6 @@ func FinishRecording(db *sql.DB, name string) error {
 			fmt.Printf(color.Ize(color.Green, "Stopped recording for %d sessions named '%s'\n"),
 				n, name)
 		} else {
-			activeSessionsAfter, err := currentSessions(db)
-			Check(err)
 			updated := getUpdatedSession(activeSessionsBefore, activeSessionsAfter)
 			updated.Finish = now
 			duration := calcDuration(updated)
@@ -265,23 +385,350 @@ func FinishRecording(db *sql.DB, name string) error {
 				name, formatDuration(duration, 2))
 		}
 	}
+	return stopped, nil
+}
+
+func FinishRecording(db *sql.DB, name string) error {
+	stopped, err := finishRecording(db, name)
+	if err != nil {
+		return err
+	}
+	err = auditFinish(db, "finish", stopped)
+	if err != nil {
+		return err
+	}
+	return recordUndo(db, "stop", stopEntries(stopped))
+}
+
+func stopEntries(stopped []Session) []undoEntry {
+	entries := make([]undoEntry, len(stopped))
+	for i, session := range stopped {
+		entries[i] = undoEntry{action: "stop", sessionID: session.ID}
+	}
+	return entries
+}
+
+// SwitchRecording finishes all running sessions and starts a new session with
+// the given name, recorded as a single command that can be undone together.
+func SwitchRecording(db *sql.DB, name string) error {
+	stopped, err := finishRecording(db, "")
+	if err != nil {
+		return err
+	}
+	id, err := startRecording(db, name)
+	if err != nil {
+		return err
+	}
+	err = auditFinish(db, "switch", stopped)
+	if err != nil {
+		return err
+	}
+	err = auditStart(db, "switch", id)
+	if err != nil {
+		return err
+	}
+	entries := append(stopEntries(stopped), undoEntry{action: "start", sessionID: id})
+	return recordUndo(db, "switch", entries)
+}
+
+// deleteSession moves a session into the trash, returning the deleted
+// session.
+func deleteSession(db *sql.DB, id int, source string) (Session, error) {
+	session, err := getSession(db, id)
+	if err != nil {
+		return session, err
+	}
+
+	err = trashSession(db, id)
+	if err != nil {
+		return session, err
+	}
+	err = auditChange(db, source, "delete", session, Session{})
+	if err != nil {
+		return session, err
+	}
+	return session, recordUndo(db, "delete", []undoEntry{{action: "delete", sessionID: id}})
+}
+
+// DeleteSession moves a session into the trash.
+func DeleteSession(db *sql.DB, id int) error {
+	session, err := deleteSession(db, id, "delete")
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Moved session [%d] %s to the trash\n"), id, displayName(session.Name))
 	return nil
 }
 
-func Reset(db *sql.DB) error {
-	stmt, err := db.Prepare("DROP TABLE IF EXISTS " + dbname)
+// stopSession finishes a single running session.
+func stopSession(db *sql.DB, id int, source string) error {
+	session, err := getSession(db, id)
 	if err != nil {
-		log.Printf("Error when preparing to drop table: %s\n", err)
 		return err
 	}
-	defer stmt.Close()
+	res, err := db.Exec("UPDATE clockin SET finish=NOW() WHERE id=? AND finish IS NULL AND deleted_at IS NULL", id)
+	if err != nil {
+		log.Printf("Error when finishing session: %s\n", err)
+		return err
+	}
+	n, err := rowsAffected(res)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("session %d is not running", id)
+	}
+
+	err = auditFinish(db, source, []Session{session})
+	if err != nil {
+		return err
+	}
+	return recordUndo(db, "stop", stopEntries([]Session{session}))
+}
+
+// SessionEdit holds the new values for an edited session, leaving any nil
+// field unchanged.
+type SessionEdit struct {
+	Name   *string
+	Start  *time.Time
+	Finish *time.Time
+}
+
+// editSession updates a session, keeping its previous values in the trash as
+// an old version of the session, and returns the updated session.
+func editSession(db *sql.DB, id int, edit SessionEdit, source string) (Session, error) {
+	session, err := getSession(db, id)
+	if err != nil {
+		return session, err
+	}
+
+	edited := session
+	if edit.Name != nil {
+		edited.Name = *edit.Name
+	}
+	if edit.Start != nil {
+		edited.Start = *edit.Start
+	}
+	if edit.Finish != nil {
+		edited.Finish = *edit.Finish
+	}
+	if len(edited.Name) > 100 {
+		return session, errors.New("name is longer than 100 characters")
+	}
+	if !edited.Finish.IsZero() && edited.Finish.Before(edited.Start) {
+		return session, errors.New("finish is before start")
+	}
+
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelfunc()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return session, err
+	}
+	defer tx.Rollback()
+
+	versionID, err := saveVersion(ctx, tx, session)
+	if err != nil {
+		log.Printf("Error when saving previous version of session: %s\n", err)
+		return session, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE clockin SET name=?, start=?, finish=? WHERE id=?",
+		edited.Name, edited.Start, nullTime(edited.Finish), id)
+	if err != nil {
+		log.Printf("Error when updating session: %s\n", err)
+		return session, err
+	}
+	err = auditChange(tx, source, "edit", session, edited)
+	if err != nil {
+		return session, err
+	}
+	err = tx.Commit()
+	if err != nil {
+		return session, err
+	}
+	return edited, recordUndo(db, "edit", []undoEntry{{action: "edit", sessionID: id, versionID: versionID}})
+}
+
+// EditSession updates a session, keeping its previous values in the trash as
+// an old version of the session.
+func EditSession(db *sql.DB, id int, edit SessionEdit) error {
+	edited, err := editSession(db, id, edit, "edit")
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Updated session [%d] %s\n"), id, displayName(edited.Name))
+	return nil
+}
+
+// ResetScope restricts a reset to sessions with a given name, sessions
+// started within a date range, or finished sessions only. The zero value
+// covers every session.
+type ResetScope struct {
+	Name     string
+	From     time.Time
+	To       time.Time
+	Finished bool
+}
+
+// everything reports whether the scope covers every session, in which case a
+// reset also empties the trash and the undo history.
+func (scope ResetScope) everything() bool {
+	return scope.Name == "" && scope.From.IsZero() && scope.To.IsZero() && !scope.Finished
+}
+
+func (scope ResetScope) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if scope.Name != "" {
+		conditions = append(conditions, "name=?")
+		args = append(args, scope.Name)
+	}
+	if !scope.From.IsZero() {
+		conditions = append(conditions, "start >= ?")
+		args = append(args, scope.From)
+	}
+	if !scope.To.IsZero() {
+		// The end date is inclusive
+		conditions = append(conditions, "start < ?")
+		args = append(args, scope.To.AddDate(0, 0, 1))
+	}
+	if scope.Finished {
+		conditions = append(conditions, "finish IS NOT NULL")
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+func printResetSummary(sessions []Session) {
+	nameCount := make(map[string]int)
+	names := []string{}
+	for _, session := range sessions {
+		name := session.Name
+		if name == "" {
+			name = "none"
+		}
+		if _, ok := nameCount[name]; !ok {
+			names = append(names, name)
+		}
+		nameCount[name]++
+	}
+	sort.Strings(names)
+
+	fmt.Printf(color.Ize(color.Yellow, "%d sessions (%s recorded, %d running) will be permanently deleted:\n"),
+		len(sessions), formatDuration(totalDuration(sessions), 2), numActive(sessions))
+	for _, name := range names {
+		fmt.Printf("        %s: %d\n", name, nameCount[name])
+	}
+}
+
+// confirm asks the user to type a word to go ahead with a command that
+// removes data.
+func confirm(word string) bool {
+	var confirmation string
+	fmt.Printf("Type '%s' to confirm: ", word)
+	fmt.Scanln(&confirmation)
+	return confirmation == word
+}
 
-	_, err = stmt.Exec()
+// Reset deletes every session within the scope after showing a summary of
+// what will be removed and asking for confirmation, unless already confirmed.
+// A reset of every session also empties the trash and the undo history. A
+// backup of all data is written before anything is deleted.
+func Reset(db *sql.DB, scope ResetScope, confirmed bool) error {
+	where, args := scope.where()
+	sessions, err := querySessions(db, where, args...)
 	if err != nil {
-		log.Printf("Error when dropping table: %s\n", err)
+		log.Printf("Error when finding sessions to reset: %s\n", err)
+		return err
+	}
+
+	// Deleted sessions, and old versions of edited ones, in the trash
+	trashed := []Session{}
+	trashedRows := 0
+	if scope.everything() {
+		trashed, err = querySessions(db, "deleted_at IS NOT NULL AND version_of IS NULL")
+		if err != nil {
+			log.Printf("Error when finding sessions in the trash: %s\n", err)
+			return err
+		}
+		err = db.QueryRow("SELECT COUNT(*) FROM clockin WHERE deleted_at IS NOT NULL").Scan(&trashedRows)
+		if err != nil {
+			log.Printf("Error when finding sessions in the trash: %s\n", err)
+			return err
+		}
+	}
+
+	if len(sessions) == 0 && trashedRows == 0 {
+		fmt.Println(color.Ize(color.Green, "No sessions to delete"))
 		return nil
 	}
 
+	printResetSummary(sessions)
+	if trashedRows > 0 {
+		fmt.Printf(color.Ize(color.Yellow, "The trash, holding %d deleted sessions and old versions, will be emptied\n"), trashedRows)
+	}
+	if !confirmed && !confirm("reset") {
+		fmt.Println(color.Ize(color.Red, "Reset cancelled"))
+		return nil
+	}
+
+	path, err := autoBackup(db)
+	if err != nil {
+		log.Printf("Error when backing up data before reset: %s\n", err)
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Backup written to %s\n"), path)
+
+	query := "DELETE FROM clockin WHERE " + where
+	if scope.everything() {
+		query = "DELETE FROM clockin"
+		args = nil
+	}
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelfunc()
+	stmt, err := db.PrepareContext(ctx, query)
+	if err != nil {
+		log.Printf("Error when preparing SQL delete statement: %s\n", err)
+		return err
+	}
+	defer stmt.Close()
+
+	res, err := stmt.ExecContext(ctx, args...)
+	if err != nil {
+		log.Printf("Error when deleting sessions: %s\n", err)
+		return err
+	}
+
+	n, err := rowsAffected(res)
+	if err != nil {
+		return err
+	}
+	for _, session := range sessions {
+		err = auditChange(db, "reset", "delete", session, Session{})
+		if err != nil {
+			return err
+		}
+	}
+	for _, session := range trashed {
+		err = auditChange(db, "reset", "purge", session, Session{})
+		if err != nil {
+			return err
+		}
+	}
+
+	if scope.everything() {
+		_, err = db.Exec("DELETE FROM clockin_undo")
+		if err != nil {
+			log.Printf("Error when clearing commands to undo: %s\n", err)
+			return err
+		}
+		fmt.Printf(color.Ize(color.Green, "Deleted %d sessions and emptied the trash\n"), int(n)-trashedRows)
+		return nil
+	}
+	err = discardStaleUndo(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Deleted %d sessions\n"), n)
 	return nil
 }
 
@@ -293,25 +740,26 @@ func rowCount(rows *sql.Rows) int {
 	return count
 }
 
-func getSession(db *sql.DB, sessionID int) Session {
-	var session Session
-	err := db.QueryRow("SELECT * FROM clockin WHERE is=?").Scan(&session)
+func getSession(db *sql.DB, sessionID int) (Session, error) {
+	sessions, err := querySessions(db, "id=? AND deleted_at IS NULL", sessionID)
 	if err != nil {
-		return Session{}
+		return Session{}, err
 	}
-
-	fmt.Println(session)
-
-	return session
+	if len(sessions) == 0 {
+		return Session{}, fmt.Errorf("session %d does not exist", sessionID)
+	}
+	return sessions[0], nil
 }
 
 func NumActiveSessions(db *sql.DB) (int, error) {
-	rows, err := db.Query("SELECT * FROM clockin WHERE finish IS NULL")
+	rows, err := db.Query("SELECT id FROM clockin WHERE finish IS NULL AND deleted_at IS NULL")
 	if err != nil {
 		log.Printf("Finding number of active sessions failed with error: %s\n", err)
 		return 0, err
 	}
 
+	defer rows.Close()
+
 	count := rowCount(rows)
 	return count, nil
 }
@@ -327,17 +775,31 @@ func RemindCurrentSessions(db *sql.DB) {
 	}
 }
 
-func currentSessions(db *sql.DB) ([]Session, error) {
-	rows, err := db.Query("SELECT * FROM clockin WHERE finish IS NULL")
+func querySessions(db *sql.DB, where string, args ...interface{}) ([]Session, error) {
+	query := "SELECT " + sessionColumns + " FROM clockin"
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	rows, err := db.Query(query, args...)
 	if err != nil {
-		log.Printf("Current sessions failed with error: %s\n", err)
 		return nil, err
 	}
+	defer rows.Close()
 
 	sessions := ExtractSessions(rows)
 	return sessions, nil
 }
 
+func currentSessions(db *sql.DB) ([]Session, error) {
+	sessions, err := querySessions(db, "finish IS NULL AND deleted_at IS NULL")
+	if err != nil {
+		log.Printf("Current sessions failed with error: %s\n", err)
+		return nil, err
+	}
+	return sessions, nil
+}
+
 func OpenDatabase() (*sql.DB, error) {
 	username, password, fromEnv := getDBLoginDetails()
 
@@ -0,0 +1,54 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestResetScope(t *testing.T) {
+	from := dateAt(2026, 1, 1, 0)
+	to := dateAt(2026, 3, 31, 0)
+	tests := []struct {
+		name       string
+		scope      ResetScope
+		everything bool
+		where      string
+		args       []interface{}
+	}{
+		{
+			name:       "everything",
+			scope:      ResetScope{},
+			everything: true,
+			where:      "deleted_at IS NULL",
+			args:       []interface{}{},
+		},
+		{
+			name:  "name",
+			scope: ResetScope{Name: "homework"},
+			where: "deleted_at IS NULL AND name=?",
+			args:  []interface{}{"homework"},
+		},
+		{
+			name:  "dates",
+			scope: ResetScope{From: from, To: to},
+			where: "deleted_at IS NULL AND start >= ? AND start < ?",
+			args:  []interface{}{from, dateAt(2026, 4, 1, 0)},
+		},
+		{
+			name:  "finished",
+			scope: ResetScope{Finished: true},
+			where: "deleted_at IS NULL AND finish IS NOT NULL",
+			args:  []interface{}{},
+		},
+	}
+
+	for _, test := range tests {
+		if got := test.scope.everything(); got != test.everything {
+			t.Errorf("%s: everything() = %t, want %t", test.name, got, test.everything)
+		}
+		where, args := test.scope.where()
+		if where != test.where || !reflect.DeepEqual(args, test.args) {
+			t.Errorf("%s: where() = %q %v, want %q %v", test.name, where, args, test.where, test.args)
+		}
+	}
+}
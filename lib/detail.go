@@ -0,0 +1,165 @@
+package clockin
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// Most bars the history chart of a name draws for days or weeks before
+// moving on to longer periods
+const historyMaxBars = 35
+
+// nameLabel is a pie chart label that can be selected to show the sessions
+// with its name.
+type nameLabel struct {
+	*widgets.Paragraph
+	name string
+}
+
+func (l *nameLabel) highlight(on bool) {
+	if on {
+		l.TextStyle.Modifier = ui.ModifierReverse
+	} else {
+		l.TextStyle.Modifier = ui.ModifierClear
+	}
+}
+
+func sessionsNamed(sessions []Session, name string) []Session {
+	named := []Session{}
+	for _, session := range sessions {
+		if session.Name == name {
+			named = append(named, session)
+		}
+	}
+	return named
+}
+
+// nameDetail returns a page of the sessions with the given name within the
+// date range of p.
+func (p *Page) nameDetail(name string) *Page {
+	detail := Page{
+		definition: pageDefinition{
+			title:   displayName(name),
+			widgets: []widgetBuilder{basicInfo, daySessionsList, historyChart},
+		},
+		data: pageData{
+			sessions:    sessionsNamed(p.data.sessions, name),
+			previous:    sessionsNamed(p.data.previous, name),
+			hasPrevious: p.data.hasPrevious,
+			start:       p.data.start,
+			end:         p.data.end,
+			name:        name,
+		},
+		area: p.area,
+	}
+	detail.buildComponents()
+	return &detail
+}
+
+// daySessionsList lists sessions under a heading for each day with the day's
+// total.
+func daySessionsList(page pageData) ui.Drawable {
+	l := widgets.NewList()
+	l.Title = "Sessions"
+	rows := []string{}
+	now := CurrentTime()
+	for _, group := range groupSessions(page.sessions, "day") {
+		rows = append(rows, fmt.Sprintf("[%s  %s](fg:label)", group.label, formatHours(totalDuration(group.sessions))))
+		for _, session := range group.sessions {
+			if session.Finish.IsZero() {
+				rows = append(rows, fmt.Sprintf("  [%d] %s - running for %s", session.ID,
+					session.Start.Format("15:04"), formatHours(now.Sub(session.Start))))
+			} else {
+				rows = append(rows, fmt.Sprintf("  [%d] %s - %s  %s", session.ID, session.Start.Format("15:04"),
+					session.Finish.Format("15:04"), formatHours(calcDuration(session))))
+			}
+		}
+	}
+	l.Rows = rows
+	l.PaddingLeft = 2
+	l.PaddingRight = 2
+	l.PaddingTop = 1
+	l.PaddingBottom = 1
+	return l
+}
+
+// historyPeriods splits the range from start to end into the periods the
+// history chart has a bar for: days, or weeks or months when there would be
+// more than historyMaxBars days. It returns the start of each period and the
+// period's name.
+func historyPeriods(start time.Time, end time.Time) ([]time.Time, string) {
+	periods := func(first time.Time, next func(time.Time) time.Time) []time.Time {
+		starts := []time.Time{}
+		for period := first; period.Before(end); period = next(period) {
+			starts = append(starts, period)
+		}
+		return starts
+	}
+	if days := periods(startOfDay(start), func(t time.Time) time.Time { return t.AddDate(0, 0, 1) }); len(days) <= historyMaxBars {
+		return days, "day"
+	}
+	if weeks := periods(startOfWeek(start, heatmapWeekStart()), func(t time.Time) time.Time { return t.AddDate(0, 0, 7) }); len(weeks) <= historyMaxBars {
+		return weeks, "week"
+	}
+	firstMonth := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, start.Location())
+	return periods(firstMonth, func(t time.Time) time.Time { return t.AddDate(0, 1, 0) }), "month"
+}
+
+// periodTotals returns the time recorded in each period, given by their
+// starts, counting sessions towards the period they started in.
+func periodTotals(sessions []Session, starts []time.Time) []time.Duration {
+	totals := make([]time.Duration, len(starts))
+	for _, session := range sessions {
+		if session.Finish.IsZero() {
+			continue
+		}
+		// The last period starting at or before the session
+		i := sort.Search(len(starts), func(i int) bool { return starts[i].After(session.Start) }) - 1
+		if i >= 0 {
+			totals[i] += calcDuration(session)
+		}
+	}
+	return totals
+}
+
+// historyChart draws the hours recorded in each day, week or month of the
+// page's date range, including those without sessions, in the colour pinned
+// to their name if there is one.
+func historyChart(page pageData) ui.Drawable {
+	start := page.start
+	if start.IsZero() {
+		start = page.end
+		if len(page.sessions) > 0 {
+			start = page.sessions[0].Start
+		}
+	}
+	starts, period := historyPeriods(start, page.end)
+	format := map[string]string{"day": "01-02", "week": "01-02", "month": "Jan06"}[period]
+
+	labels := make([]string, len(starts))
+	for i, periodStart := range starts {
+		labels[i] = periodStart.Format(format)
+	}
+	bc := newFittedBarChart(labels, 5)
+	for _, total := range periodTotals(page.sessions, starts) {
+		bc.Data = append(bc.Data, roundFloat(total.Hours(), 1))
+	}
+	bc.Title = "Hours per " + period
+	bc.BarColors = []ui.Color{activeTheme.bars[0]}
+	if len(page.sessions) > 0 {
+		bc.BarColors = []ui.Color{activeTheme.nameColor(page.sessions[0].Name)}
+	}
+	bc.LabelStyles = []ui.Style{ui.NewStyle(activeTheme.label)}
+	bc.NumStyles = []ui.Style{ui.NewStyle(activeTheme.numbers)}
+	bc.NumFormatter = func(v float64) string {
+		return fmt.Sprintf("%.1f", v)
+	}
+	bc.PaddingTop = 1
+	bc.PaddingLeft = 2
+	bc.PaddingRight = 2
+	return bc
+}
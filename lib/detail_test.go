@@ -0,0 +1,44 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestHistoryPeriods(t *testing.T) {
+	tests := []struct {
+		name       string
+		start, end time.Time
+		period     string
+		count      int
+	}{
+		{name: "today", start: dateAt(2026, 10, 12, 0), end: dateAt(2026, 10, 13, 0), period: "day", count: 1},
+		{name: "last week", start: dateAt(2026, 10, 5, 15), end: dateAt(2026, 10, 12, 15), period: "day", count: 8},
+		{name: "quarter", start: dateAt(2026, 7, 15, 15), end: dateAt(2026, 10, 14, 15), period: "week", count: 14},
+		{name: "year", start: dateAt(2025, 10, 12, 15), end: dateAt(2026, 10, 12, 15), period: "month", count: 13},
+	}
+
+	for _, test := range tests {
+		starts, period := historyPeriods(test.start, test.end)
+		if period != test.period || len(starts) != test.count {
+			t.Errorf("%s: got %d periods of a %s, want %d of a %s", test.name, len(starts), period, test.count, test.period)
+		}
+	}
+}
+
+func TestPeriodTotals(t *testing.T) {
+	h := time.Hour
+	starts := []time.Time{dateAt(2026, 10, 10, 0), dateAt(2026, 10, 11, 0), dateAt(2026, 10, 12, 0)}
+	sessions := []Session{
+		{Start: dateAt(2026, 10, 9, 9), Finish: dateAt(2026, 10, 9, 10)},
+		{Start: dateAt(2026, 10, 10, 9), Finish: dateAt(2026, 10, 10, 11)},
+		{Start: dateAt(2026, 10, 12, 0), Finish: dateAt(2026, 10, 12, 1)},
+		{Start: dateAt(2026, 10, 12, 9), Finish: dateAt(2026, 10, 12, 12)},
+		{Start: dateAt(2026, 10, 12, 13)},
+	}
+	want := []time.Duration{2 * h, 0, 4 * h}
+	if got := periodTotals(sessions, starts); !reflect.DeepEqual(got, want) {
+		t.Errorf("periodTotals() = %v, want %v", got, want)
+	}
+}
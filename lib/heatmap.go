@@ -0,0 +1,336 @@
+package clockin
+
+import (
+	"database/sql"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/TwiN/go-color"
+	ui "github.com/gizak/termui/v3"
+)
+
+// Number of levels of the heatmap, from days with no recorded time to the
+// busiest days, each drawn in a colour of the theme
+const heatmapLevels = 5
+
+// Width of the weekday labels to the left of the heatmap
+const heatmapLabelWidth = 4
+
+// heatmap holds the time recorded on each day of the last year, starting at
+// the beginning of a week so that each column of the grid is one week.
+type heatmap struct {
+	days       []time.Time
+	totals     []time.Duration
+	levels     []int
+	thresholds []time.Duration
+}
+
+// heatmapStart returns the first day shown in a heatmap ending on the day of
+// now, which is the start of the week a year before.
+func heatmapStart(now time.Time, weekStart time.Weekday) time.Time {
+	return startOfWeek(now.AddDate(0, 0, -364), weekStart)
+}
+
+// quantileThresholds returns the upper bounds of the lower levels of the
+// heatmap, splitting the days with recorded time into quarters.
+func quantileThresholds(totals []time.Duration) []time.Duration {
+	worked := []time.Duration{}
+	for _, total := range totals {
+		if total > 0 {
+			worked = append(worked, total)
+		}
+	}
+	if len(worked) == 0 {
+		return nil
+	}
+	sort.Slice(worked, func(i, j int) bool { return worked[i] < worked[j] })
+
+	thresholds := make([]time.Duration, heatmapLevels-2)
+	for i := range thresholds {
+		thresholds[i] = worked[(i+1)*len(worked)/(len(thresholds)+1)]
+	}
+	return thresholds
+}
+
+func heatmapLevel(total time.Duration, thresholds []time.Duration) int {
+	if total <= 0 {
+		return 0
+	}
+	level := 1
+	for _, threshold := range thresholds {
+		if total > threshold {
+			level++
+		}
+	}
+	return level
+}
+
+// dailyTotals returns the time recorded on each of the given consecutive
+// days. Sessions crossing midnight count towards each day they span.
+func dailyTotals(sessions []Session, days []time.Time) []time.Duration {
+	totals := make([]time.Duration, len(days))
+	if len(days) == 0 {
+		return totals
+	}
+	first := days[0]
+	for _, session := range sessions {
+		if session.Finish.IsZero() {
+			continue
+		}
+		// Only look at the days the session spans
+		from := int(startOfDay(session.Start).Sub(first).Hours() / 24)
+		to := int(startOfDay(session.Finish).Sub(first).Hours()/24) + 1
+		if from < 0 {
+			from = 0
+		}
+		if to > len(days) {
+			to = len(days)
+		}
+		if from >= to {
+			continue
+		}
+		for i, duration := range durationPerDay(session, days[from:to]) {
+			totals[from+i] += duration
+		}
+	}
+	return totals
+}
+
+// daysBetween returns each day from first to last, inclusive.
+func daysBetween(first time.Time, last time.Time) []time.Time {
+	days := []time.Time{}
+	for day := first; !day.After(last); day = day.AddDate(0, 0, 1) {
+		days = append(days, day)
+	}
+	return days
+}
+
+func buildHeatmap(sessions []Session, now time.Time, weekStart time.Weekday) heatmap {
+	h := heatmap{days: daysBetween(heatmapStart(now, weekStart), now)}
+	h.totals = dailyTotals(sessions, h.days)
+	h.thresholds = quantileThresholds(h.totals)
+	h.levels = make([]int, len(h.days))
+	for i, total := range h.totals {
+		h.levels[i] = heatmapLevel(total, h.thresholds)
+	}
+	return h
+}
+
+func (h heatmap) weeks() int {
+	return (len(h.days) + 6) / 7
+}
+
+// level returns the level of a day in the grid, or false for days after the
+// last day of the heatmap.
+func (h heatmap) level(week int, weekday int) (int, bool) {
+	i := week*7 + weekday
+	if i >= len(h.days) {
+		return 0, false
+	}
+	return h.levels[i], true
+}
+
+// monthRow returns the labels shown above the grid, with each month's name
+// above the first week starting in that month.
+func (h heatmap) monthRow() string {
+	row := []rune(strings.Repeat(" ", h.weeks()*2))
+	end := 0
+	for week := 0; week < h.weeks(); week++ {
+		day := h.days[week*7]
+		if week > 0 && day.Month() == h.days[(week-1)*7].Month() {
+			continue
+		}
+		label := day.Format("Jan")
+		position := week * 2
+		if position < end || position+len(label) > len(row) {
+			continue
+		}
+		copy(row[position:], []rune(label))
+		end = position + len(label) + 1
+	}
+	return strings.TrimRight(string(row), " ")
+}
+
+// weekdayLabel returns the label of a row of the grid. Every other row is
+// labelled, as there is only room for short labels.
+func (h heatmap) weekdayLabel(weekday int) string {
+	if weekday%2 == 1 {
+		return ""
+	}
+	return h.days[weekday].Format("Mon")
+}
+
+// summary describes the total time and the busiest day in the heatmap.
+func (h heatmap) summary() string {
+	var total time.Duration
+	daysWorked := 0
+	busiest := 0
+	for i, duration := range h.totals {
+		total += duration
+		if duration > 0 {
+			daysWorked++
+		}
+		if duration > h.totals[busiest] {
+			busiest = i
+		}
+	}
+	if daysWorked == 0 {
+		return "No completed sessions in the last year"
+	}
+	return fmt.Sprintf("%s on %d days, busiest %s (%s)", formatHours(total), daysWorked,
+		h.days[busiest].Format("2 Jan 2006"), formatHours(h.totals[busiest]))
+}
+
+func (h heatmap) writeText(w io.Writer) {
+	indent := strings.Repeat(" ", heatmapLabelWidth)
+	fmt.Fprintf(w, "%s%s\n", indent, h.monthRow())
+	for weekday := 0; weekday < 7; weekday++ {
+		var row strings.Builder
+		fmt.Fprintf(&row, "%-*s", heatmapLabelWidth, h.weekdayLabel(weekday))
+		for week := 0; week < h.weeks(); week++ {
+			level, ok := h.level(week, weekday)
+			if !ok {
+				break
+			}
+			row.WriteString(color.Ize(ansiColor(activeTheme.heatmap[level]), string(activeTheme.heatmapBlocks[level])) + " ")
+		}
+		fmt.Fprintln(w, strings.TrimRight(row.String(), " "))
+	}
+
+	legend := []string{}
+	for level, c := range activeTheme.heatmap {
+		legend = append(legend, color.Ize(ansiColor(c), string(activeTheme.heatmapBlocks[level])))
+	}
+	fmt.Fprintf(w, "\n%sLess %s More\n", indent, strings.Join(legend, " "))
+	fmt.Fprintf(w, "%s%s\n", indent, h.summary())
+}
+
+// lastWeeks returns the part of the heatmap covering its most recent weeks.
+func (h heatmap) lastWeeks(weeks int) heatmap {
+	if weeks >= h.weeks() {
+		return h
+	}
+	if weeks < 0 {
+		weeks = 0
+	}
+	first := (h.weeks() - weeks) * 7
+	return heatmap{
+		days:       h.days[first:],
+		totals:     h.totals[first:],
+		levels:     h.levels[first:],
+		thresholds: h.thresholds,
+	}
+}
+
+// heatmapWidget draws a heatmap in the stats page, showing as many of the
+// most recent weeks as fit in its width.
+type heatmapWidget struct {
+	ui.Block
+	heatmap heatmap
+}
+
+// setString draws text cut short at the edges of the widget.
+func (hw *heatmapWidget) setString(buf *ui.Buffer, s string, style ui.Style, p image.Point) {
+	width := hw.Inner.Max.X - p.X
+	if width <= 0 || p.Y >= hw.Inner.Max.Y {
+		return
+	}
+	if text := []rune(s); len(text) > width {
+		s = string(text[:width])
+	}
+	buf.SetString(s, style, p)
+}
+
+func (hw *heatmapWidget) setCell(buf *ui.Buffer, cell ui.Cell, p image.Point) {
+	if p.In(hw.Inner) {
+		buf.SetCell(cell, p)
+	}
+}
+
+func (hw *heatmapWidget) Draw(buf *ui.Buffer) {
+	hw.Block.Draw(buf)
+	x := hw.Inner.Min.X
+	y := hw.Inner.Min.Y
+	// Each week takes a column for its block and one for the space after it,
+	// which the last week can do without
+	h := hw.heatmap.lastWeeks((hw.Inner.Dx() - heatmapLabelWidth + 1) / 2)
+
+	hw.setString(buf, h.monthRow(), ui.NewStyle(activeTheme.label), image.Pt(x+heatmapLabelWidth, y))
+	for weekday := 0; weekday < 7; weekday++ {
+		hw.setString(buf, hw.heatmap.weekdayLabel(weekday), ui.NewStyle(activeTheme.label), image.Pt(x, y+1+weekday))
+		for week := 0; week < h.weeks(); week++ {
+			level, ok := h.level(week, weekday)
+			if !ok {
+				break
+			}
+			cell := ui.NewCell(activeTheme.heatmapBlocks[level], ui.NewStyle(activeTheme.heatmap[level]))
+			hw.setCell(buf, cell, image.Pt(x+heatmapLabelWidth+week*2, y+1+weekday))
+		}
+	}
+
+	legendX := x + heatmapLabelWidth
+	hw.setString(buf, "Less", ui.NewStyle(ui.ColorClear), image.Pt(legendX, y+8))
+	for level, c := range activeTheme.heatmap {
+		cell := ui.NewCell(activeTheme.heatmapBlocks[level], ui.NewStyle(c))
+		hw.setCell(buf, cell, image.Pt(legendX+5+level*2, y+8))
+	}
+	moreX := legendX + 5 + heatmapLevels*2
+	hw.setString(buf, "More", ui.NewStyle(ui.ColorClear), image.Pt(moreX, y+8))
+
+	// The summary of the whole year follows the legend if there is room, or
+	// goes below it
+	summary := hw.heatmap.summary()
+	summaryPoint := image.Pt(moreX+6, y+8)
+	if summaryPoint.X+len(summary) > hw.Inner.Max.X {
+		summaryPoint = image.Pt(legendX, y+9)
+	}
+	hw.setString(buf, summary, ui.NewStyle(ui.ColorClear), summaryPoint)
+}
+
+// heatmapWeekStart returns the configured first day of the week, falling
+// back to Monday if the config cannot be read.
+func heatmapWeekStart() time.Weekday {
+	weekStart, err := loadWeekStart("")
+	if err != nil {
+		return time.Monday
+	}
+	return weekStart
+}
+
+func heatmapRange(now time.Time) DateRange {
+	return DateRange{Start: heatmapStart(now, heatmapWeekStart())}
+}
+
+func heatmapChart(page pageData) ui.Drawable {
+	hw := &heatmapWidget{
+		Block:   *ui.NewBlock(),
+		heatmap: buildHeatmap(page.sessions, startOfDay(CurrentTime()), heatmapWeekStart()),
+	}
+	hw.Title = "Daily time over the last year"
+	hw.PaddingLeft = 1
+	return hw
+}
+
+// Heatmap prints a grid of the time recorded on each day over the last year,
+// one column per week, with darker to brighter colours for busier days.
+func Heatmap(db *sql.DB, weekStartSetting string) error {
+	weekStart, err := loadWeekStart(weekStartSetting)
+	if err != nil {
+		return err
+	}
+
+	today := startOfDay(CurrentTime())
+	first := heatmapStart(today, weekStart)
+	sessions, err := querySessions(db, "deleted_at IS NULL AND finish IS NOT NULL AND finish > ?", first)
+	if err != nil {
+		return err
+	}
+
+	buildHeatmap(sessions, today, weekStart).writeText(os.Stdout)
+	return nil
+}
@@ -0,0 +1,29 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestQuantileThresholds(t *testing.T) {
+	h := time.Hour
+	tests := []struct {
+		name   string
+		totals []time.Duration
+		want   []time.Duration
+	}{
+		{name: "no days", totals: nil, want: nil},
+		{name: "no time recorded", totals: []time.Duration{0, 0, 0}, want: nil},
+		{name: "one day", totals: []time.Duration{0, 5 * h, 0}, want: []time.Duration{5 * h, 5 * h, 5 * h}},
+		{name: "quarters", totals: []time.Duration{0, h, 2 * h, 3 * h, 4 * h}, want: []time.Duration{2 * h, 3 * h, 4 * h}},
+		{name: "unsorted", totals: []time.Duration{3 * h, 0, h}, want: []time.Duration{h, 3 * h, 3 * h}},
+	}
+
+	for _, test := range tests {
+		got := quantileThresholds(test.totals)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: quantileThresholds(%v) = %v, want %v", test.name, test.totals, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,154 @@
+package clockin
+
+import (
+	"fmt"
+	"image"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+var weekdayLabels = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
+
+// hourlyDurations returns the time recorded in each hour of the day on each
+// weekday, starting from Monday. Sessions are split at the hour boundaries
+// they cross, so a session from 9:30 to 11:00 adds 30 minutes to 9:00 and an
+// hour to 10:00.
+func hourlyDurations(sessions []Session) [7][24]time.Duration {
+	var durations [7][24]time.Duration
+	for _, session := range sessions {
+		if session.Finish.IsZero() {
+			continue
+		}
+		start := session.Start
+		for start.Before(session.Finish) {
+			end := start.Truncate(time.Hour).Add(time.Hour)
+			if end.After(session.Finish) {
+				end = session.Finish
+			}
+			durations[(int(start.Weekday())+6)%7][start.Hour()] += end.Sub(start)
+			start = end
+		}
+	}
+	return durations
+}
+
+// hourTotals adds up the time recorded in each hour over all weekdays.
+func hourTotals(durations [7][24]time.Duration) []time.Duration {
+	totals := make([]time.Duration, 24)
+	for _, day := range durations {
+		for hour, duration := range day {
+			totals[hour] += duration
+		}
+	}
+	return totals
+}
+
+// buildHourTable lists the time recorded in each hour of the day, with a
+// column for each weekday if byWeekday is set.
+func buildHourTable(sessions []Session, byWeekday bool) textTable {
+	durations := hourlyDurations(sessions)
+	totals := hourTotals(durations)
+	total := sumDurations(totals)
+
+	table := textTable{headers: []string{"Hour"}, alignRight: []bool{false}}
+	if byWeekday {
+		table.headers = append(table.headers, weekdayLabels...)
+		for range weekdayLabels {
+			table.alignRight = append(table.alignRight, true)
+		}
+	}
+	table.headers = append(table.headers, "Duration", "Share")
+	table.alignRight = append(table.alignRight, true, true)
+
+	for hour, duration := range totals {
+		row := []string{fmt.Sprintf("%02d:00", hour)}
+		if byWeekday {
+			for weekday := range weekdayLabels {
+				row = append(row, formatTimesheetCell(durations[weekday][hour]))
+			}
+		}
+		row = append(row, formatHours(duration), formatPercentage(duration, total))
+		table.addRow(row...)
+	}
+
+	table.addSeparator()
+	row := []string{"Total"}
+	if byWeekday {
+		for weekday := range weekdayLabels {
+			row = append(row, formatHours(sumDurations(durations[weekday][:])))
+		}
+	}
+	table.addRow(append(row, formatHours(total), formatPercentage(total, total))...)
+	return table
+}
+
+func hourOfDay(page pageData) ui.Drawable {
+	bc := widgets.NewBarChart()
+	data := make([]float64, 24)
+	labels := make([]string, 24)
+	for hour, duration := range hourTotals(hourlyDurations(page.sessions)) {
+		data[hour] = roundFloat(duration.Minutes(), 0)
+		labels[hour] = fmt.Sprintf("%02d", hour)
+	}
+
+	bc.Data = data
+	bc.Labels = labels
+	bc.Title = "Hours of the day"
+	bc.BarWidth = 2
+	bc.BarGap = 0
+	// Alternate colours to tell adjacent hours apart, as the bars are too
+	// narrow to show the minutes within them
+	bc.BarColors = activeTheme.bars
+	bc.LabelStyles = []ui.Style{ui.NewStyle(activeTheme.label)}
+	bc.NumFormatter = func(v float64) string {
+		return ""
+	}
+	bc.PaddingTop = 1
+	bc.PaddingLeft = 2
+	bc.PaddingRight = 2
+	return bc
+}
+
+// weekdayHoursWidget shades each hour of each weekday by the time recorded
+// in it, using the heatmap colours.
+type weekdayHoursWidget struct {
+	ui.Block
+	durations [7][24]time.Duration
+}
+
+func (w *weekdayHoursWidget) Draw(buf *ui.Buffer) {
+	w.Block.Draw(buf)
+	x := w.Inner.Min.X
+	y := w.Inner.Min.Y
+
+	all := []time.Duration{}
+	for _, day := range w.durations {
+		all = append(all, day[:]...)
+	}
+	thresholds := quantileThresholds(all)
+
+	for hour := 0; hour < 24; hour += 3 {
+		buf.SetString(fmt.Sprintf("%02d", hour), ui.NewStyle(activeTheme.label),
+			image.Pt(x+heatmapLabelWidth+hour*2, y))
+	}
+	for weekday, label := range weekdayLabels {
+		buf.SetString(label, ui.NewStyle(activeTheme.label), image.Pt(x, y+1+weekday))
+		for hour, duration := range w.durations[weekday] {
+			level := heatmapLevel(duration, thresholds)
+			cell := ui.NewCell(activeTheme.heatmapBlocks[level], ui.NewStyle(activeTheme.heatmap[level]))
+			buf.SetCell(cell, image.Pt(x+heatmapLabelWidth+hour*2, y+1+weekday))
+		}
+	}
+}
+
+func weekdayHours(page pageData) ui.Drawable {
+	w := &weekdayHoursWidget{
+		Block:     *ui.NewBlock(),
+		durations: hourlyDurations(page.sessions),
+	}
+	w.Title = "Hours by weekday"
+	w.PaddingLeft = 1
+	return w
+}
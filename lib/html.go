@@ -0,0 +1,286 @@
+package clockin
+
+import (
+	"fmt"
+	"html/template"
+	"io"
+	"math"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// Shades for the eight basic terminal colours, softer than the usual xterm
+// ones so they read well on a white page
+var htmlBasicColors = []string{"#222222", "#e05252", "#4caf50", "#e0c040", "#4a7fd4", "#b45ac8", "#40bcc8", "#dddddd"}
+
+// Greys that tell slices apart when the theme leaves names uncoloured
+var htmlGreys = []string{"#444444", "#777777", "#999999", "#bbbbbb", "#5e5e5e", "#d0d0d0"}
+
+type htmlSlice struct {
+	Label      string
+	Color      string
+	Path       string
+	Duration   string
+	Percentage string
+}
+
+type htmlBar struct {
+	Label    string
+	X        int
+	Y        int
+	Height   int
+	Duration string
+}
+
+type htmlSession struct {
+	ID       int
+	Name     string
+	Start    string
+	Finish   string
+	Duration string
+	Running  bool
+}
+
+type htmlReport struct {
+	Title       string
+	Generated   string
+	Total       string
+	Completed   int
+	Active      int
+	Slices      []htmlSlice
+	FullCircle  bool
+	WeekdayBars []htmlBar
+	Sessions    []htmlSession
+}
+
+// htmlColor converts a 256-colour code to a CSS colour. The default colour
+// has no equivalent on the page, so it falls back to a grey picked by the
+// slice's position.
+func htmlColor(c ui.Color, i int) string {
+	switch {
+	case c == ui.ColorClear:
+		return htmlGreys[i%len(htmlGreys)]
+	case c < 8:
+		return htmlBasicColors[c]
+	case c < 16:
+		// The bright versions of the basic colours, as xterm draws them
+		bright := []string{"#808080", "#ff0000", "#00ff00", "#ffff00", "#5c5cff", "#ff00ff", "#00ffff", "#ffffff"}
+		return bright[c-8]
+	case c < 232:
+		// A 6x6x6 cube of red, green and blue levels
+		levels := []int{0, 95, 135, 175, 215, 255}
+		n := int(c) - 16
+		return fmt.Sprintf("#%02x%02x%02x", levels[n/36], levels[n/6%6], levels[n%6])
+	default:
+		grey := 8 + 10*(int(c)-232)
+		return fmt.Sprintf("#%02x%02x%02x", grey, grey, grey)
+	}
+}
+
+// pieSlices groups name durations into at most n slices, longest first, with
+// any remaining names combined into an "Other" slice.
+func pieSlices(nameTime map[string]time.Duration, n int) ([]string, []time.Duration) {
+	names := sortedNames(nameTime)
+	slices := []string{}
+	durations := []time.Duration{}
+	for i, name := range names {
+		if i < n-1 || len(names) == n {
+			slices = append(slices, name)
+			durations = append(durations, nameTime[name])
+		} else if i == n-1 {
+			slices = append(slices, "Other")
+			durations = append(durations, nameTime[name])
+		} else {
+			durations[n-1] += nameTime[name]
+		}
+	}
+	return slices, durations
+}
+
+// weekdayCounts returns how many times each weekday falls between start and
+// end, starting from Monday. Days the range only partly covers are counted.
+func weekdayCounts(start time.Time, end time.Time) []int {
+	counts := make([]int, 7)
+	for day := startOfDay(start); day.Before(end); day = day.AddDate(0, 0, 1) {
+		counts[(int(day.Weekday())+6)%7]++
+	}
+	return counts
+}
+
+// weekdayAverages returns the average time recorded on each weekday between
+// start and end, starting from Monday. A zero start is taken from the first
+// session.
+func weekdayAverages(sessions []Session, start time.Time, end time.Time) []time.Duration {
+	if start.IsZero() && len(sessions) > 0 {
+		start = sessions[0].Start
+	}
+	averages := make([]time.Duration, 7)
+	for _, session := range sessions {
+		if !session.Finish.IsZero() {
+			averages[(int(session.Start.Weekday())+6)%7] += calcDuration(session)
+		}
+	}
+	for i, count := range weekdayCounts(start, end) {
+		if count > 0 {
+			averages[i] /= time.Duration(count)
+		}
+	}
+	return averages
+}
+
+func arcPath(cx float64, cy float64, r float64, startAngle float64, endAngle float64) string {
+	x1 := cx + r*math.Cos(startAngle)
+	y1 := cy + r*math.Sin(startAngle)
+	x2 := cx + r*math.Cos(endAngle)
+	y2 := cy + r*math.Sin(endAngle)
+	largeArc := 0
+	if endAngle-startAngle > math.Pi {
+		largeArc = 1
+	}
+	return fmt.Sprintf("M %.2f %.2f L %.2f %.2f A %.2f %.2f 0 %d 1 %.2f %.2f Z",
+		cx, cy, x1, y1, r, r, largeArc, x2, y2)
+}
+
+// buildHTMLReport lays out the report of sessions started between start and
+// end.
+func buildHTMLReport(sessions []Session, start time.Time, end time.Time, description string) htmlReport {
+	total := totalDuration(sessions)
+	report := htmlReport{
+		Title:     "clockin report for " + description,
+		Generated: CurrentTime().Format("2006-01-02 15:04"),
+		Total:     formatDuration(total, 3),
+		Completed: numCompleted(sessions),
+		Active:    numActive(sessions),
+	}
+
+	// Slices are coloured as in the stats page, following the theme and any
+	// colours pinned to names in the config
+	names, durations := pieSlices(nameDurations(sessions), len(activeTheme.names))
+	if total == 0 {
+		names = nil
+	}
+	colors := activeTheme.nameColors(names)
+	// Slices start from the top of the circle, as in the stats page
+	angle := -0.5 * math.Pi
+	for i, name := range names {
+		sweep := 2 * math.Pi * float64(durations[i]) / float64(total)
+		report.Slices = append(report.Slices, htmlSlice{
+			Label:      displayName(name),
+			Color:      htmlColor(colors[i], i),
+			Path:       arcPath(100, 100, 90, angle, angle+sweep),
+			Duration:   formatHours(durations[i]),
+			Percentage: formatPercentage(durations[i], total),
+		})
+		angle += sweep
+	}
+	report.FullCircle = len(report.Slices) == 1
+
+	weekdays := weekdayAverages(sessions, start, end)
+	var longest time.Duration
+	for _, duration := range weekdays {
+		if duration > longest {
+			longest = duration
+		}
+	}
+	for i, label := range weekdayLabels {
+		height := 0
+		if longest > 0 {
+			height = int(150 * float64(weekdays[i]) / float64(longest))
+		}
+		report.WeekdayBars = append(report.WeekdayBars, htmlBar{
+			Label:    label,
+			X:        10 + i*50,
+			Y:        170 - height,
+			Height:   height,
+			Duration: formatHours(weekdays[i]),
+		})
+	}
+
+	for _, session := range sessions {
+		s := htmlSession{
+			ID:      session.ID,
+			Name:    displayName(session.Name),
+			Start:   session.Start.Format("2006-01-02 15:04"),
+			Running: session.Finish.IsZero(),
+		}
+		if s.Running {
+			s.Finish = "running"
+		} else {
+			s.Finish = session.Finish.Format("2006-01-02 15:04")
+			s.Duration = formatHours(calcDuration(session))
+		}
+		report.Sessions = append(report.Sessions, s)
+	}
+	return report
+}
+
+func writeHTMLReport(w io.Writer, sessions []Session, start time.Time, end time.Time, description string) error {
+	return htmlTemplate.Execute(w, buildHTMLReport(sessions, start, end, description))
+}
+
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; color: #222; max-width: 960px; margin: 2em auto; padding: 0 1em; }
+h1 { font-size: 1.5em; margin-bottom: 0; }
+.generated { color: #777; margin-top: 0.3em; }
+.panels { display: flex; flex-wrap: wrap; gap: 1em; margin: 1.5em 0; }
+.panel { border: 1px solid #ddd; border-radius: 6px; padding: 1em 1.2em; flex: 1 1 200px; }
+.panel h2 { font-size: 0.9em; color: #555; margin: 0 0 0.5em; font-weight: normal; }
+.value { font-size: 1.4em; color: #2e7d32; }
+.active { color: #b8860b; }
+.legend { list-style: none; padding: 0; margin: 0; }
+.legend li { margin: 0.3em 0; }
+.swatch { display: inline-block; width: 0.8em; height: 0.8em; margin-right: 0.4em; border-radius: 2px; }
+svg text { font-size: 11px; fill: #555; }
+table { border-collapse: collapse; width: 100%; }
+th, td { text-align: left; padding: 0.35em 0.6em; border-bottom: 1px solid #eee; }
+td.number { text-align: right; font-variant-numeric: tabular-nums; }
+tr.running td { color: #b8860b; }
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="generated">Generated {{.Generated}}</p>
+
+<div class="panels">
+<div class="panel"><h2>Total duration</h2><div class="value">{{.Total}}</div></div>
+<div class="panel"><h2>Completed</h2><div class="value">{{.Completed}}</div></div>
+<div class="panel"><h2>Active</h2><div class="value active">{{.Active}}</div></div>
+</div>
+
+<div class="panels">
+<div class="panel">
+<h2>Session names</h2>
+{{if .Slices}}
+<svg width="200" height="200" viewBox="0 0 200 200" role="img" aria-label="Time by session name">
+{{if .FullCircle}}{{with index .Slices 0}}<circle cx="100" cy="100" r="90" fill="{{.Color}}"/>{{end}}{{else}}{{range .Slices}}<path d="{{.Path}}" fill="{{.Color}}" stroke="#fff" stroke-width="1"/>
+{{end}}{{end}}</svg>
+<ul class="legend">
+{{range .Slices}}<li><span class="swatch" style="background: {{.Color}}"></span>{{.Label}}: {{.Duration}} ({{.Percentage}})</li>
+{{end}}</ul>
+{{else}}<p>No completed sessions</p>{{end}}
+</div>
+<div class="panel">
+<h2>Average time per weekday</h2>
+<svg width="360" height="200" viewBox="0 0 360 200" role="img" aria-label="Average time per weekday">
+{{range .WeekdayBars}}<rect x="{{.X}}" y="{{.Y}}" width="36" height="{{.Height}}" fill="#4caf50"><title>{{.Duration}}</title></rect>
+<text x="{{.X}}" y="190">{{.Label}}</text>
+{{end}}</svg>
+</div>
+</div>
+
+<h2>Sessions</h2>
+<table>
+<thead><tr><th>ID</th><th>Name</th><th>Start</th><th>Finish</th><th>Duration</th></tr></thead>
+<tbody>
+{{range .Sessions}}<tr{{if .Running}} class="running"{{end}}><td class="number">{{.ID}}</td><td>{{.Name}}</td><td>{{.Start}}</td><td>{{.Finish}}</td><td class="number">{{.Duration}}</td></tr>
+{{end}}</tbody>
+</table>
+</body>
+</html>
+`))
@@ -0,0 +1,82 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+func TestHTMLColor(t *testing.T) {
+	tests := []struct {
+		color ui.Color
+		index int
+		want  string
+	}{
+		{color: ui.ColorRed, want: "#e05252"},
+		{color: 9, want: "#ff0000"},
+		{color: 16, want: "#000000"},
+		{color: 214, want: "#ffaf00"},
+		{color: 231, want: "#ffffff"},
+		{color: 244, want: "#808080"},
+		{color: ui.ColorClear, index: 1, want: "#777777"},
+	}
+
+	for _, test := range tests {
+		if got := htmlColor(test.color, test.index); got != test.want {
+			t.Errorf("htmlColor(%d, %d) = %s, want %s", test.color, test.index, got, test.want)
+		}
+	}
+}
+
+func TestHTMLReportColors(t *testing.T) {
+	saved := activeTheme
+	defer func() { activeTheme = saved }()
+	activeTheme = themes["colour-blind"]
+	activeTheme.pinned = map[string]ui.Color{"writing": ui.ColorRed}
+
+	sessions := []Session{
+		{ID: 1, Name: "coding", Start: dateAt(2026, 10, 5, 9), Finish: dateAt(2026, 10, 5, 12)},
+		{ID: 2, Name: "writing", Start: dateAt(2026, 10, 5, 13), Finish: dateAt(2026, 10, 5, 14)},
+	}
+	report := buildHTMLReport(sessions, dateAt(2026, 10, 5, 0), dateAt(2026, 10, 12, 0), "a test")
+	want := []string{"#ffaf00", "#e05252"}
+	if len(report.Slices) != len(want) {
+		t.Fatalf("got %d slices, want %d", len(report.Slices), len(want))
+	}
+	for i, slice := range report.Slices {
+		if slice.Color != want[i] {
+			t.Errorf("slice %s has colour %s, want %s", slice.Label, slice.Color, want[i])
+		}
+	}
+}
+
+func TestWeekdayAverages(t *testing.T) {
+	h := time.Hour
+	// Two Mondays and one of every other day, from Monday 5 to Monday 12
+	start, end := dateAt(2026, 10, 5, 0), dateAt(2026, 10, 13, 0)
+	sessions := []Session{
+		{Start: dateAt(2026, 10, 5, 9), Finish: dateAt(2026, 10, 5, 12)},
+		{Start: dateAt(2026, 10, 7, 9), Finish: dateAt(2026, 10, 7, 11)},
+		{Start: dateAt(2026, 10, 12, 9), Finish: dateAt(2026, 10, 12, 10)},
+		{Start: dateAt(2026, 10, 12, 13)},
+	}
+
+	tests := []struct {
+		name  string
+		start time.Time
+		want  []time.Duration
+	}{
+		{name: "range", start: start, want: []time.Duration{2 * h, 0, 2 * h, 0, 0, 0, 0}},
+		{name: "all time", start: time.Time{}, want: []time.Duration{2 * h, 0, 2 * h, 0, 0, 0, 0}},
+		{name: "earlier start", start: dateAt(2026, 9, 28, 0), want: []time.Duration{h + 20*time.Minute, 0, h, 0, 0, 0, 0}},
+	}
+
+	for _, test := range tests {
+		got := weekdayAverages(sessions, test.start, end)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("%s: weekdayAverages() = %v, want %v", test.name, got, test.want)
+		}
+	}
+}
@@ -0,0 +1,574 @@
+package clockin
+
+import (
+	"bufio"
+	"database/sql"
+	"fmt"
+	"log"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/TwiN/go-color"
+)
+
+type calendarProperty struct {
+	name   string
+	params map[string]string
+	value  string
+}
+
+type calendarEvent struct {
+	uid          string
+	summary      string
+	status       string
+	start        time.Time
+	end          time.Time
+	duration     time.Duration
+	allDay       bool
+	rrule        string
+	exdates      []time.Time
+	recurrenceID time.Time
+}
+
+type occurrence struct {
+	summary string
+	start   time.Time
+	end     time.Time
+}
+
+// Upper limit on the number of candidate dates considered when expanding a
+// single recurrence rule, in case of rules that never reach the date window
+const maxRecurrenceIterations = 100000
+
+func unfoldLines(f *os.File) ([]string, error) {
+	lines := []string{}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		if (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) && len(lines) > 0 {
+			// Continuation of the previous content line
+			lines[len(lines)-1] += line[1:]
+		} else if line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
+
+func parseProperty(line string) calendarProperty {
+	// Property name and parameters are separated from the value by the first
+	// colon that is not inside a quoted parameter value
+	inQuotes := false
+	split := -1
+	for i, c := range line {
+		if c == '"' {
+			inQuotes = !inQuotes
+		} else if c == ':' && !inQuotes {
+			split = i
+			break
+		}
+	}
+
+	prop := calendarProperty{params: make(map[string]string)}
+	if split == -1 {
+		prop.name = strings.ToUpper(line)
+		return prop
+	}
+	prop.value = line[split+1:]
+
+	parts := strings.Split(line[:split], ";")
+	prop.name = strings.ToUpper(parts[0])
+	for _, param := range parts[1:] {
+		if key, value, ok := strings.Cut(param, "="); ok {
+			prop.params[strings.ToUpper(key)] = strings.Trim(value, "\"")
+		}
+	}
+	return prop
+}
+
+func unescapeText(value string) string {
+	replacer := strings.NewReplacer(`\\`, `\`, `\;`, `;`, `\,`, `,`, `\n`, " ", `\N`, " ")
+	return replacer.Replace(value)
+}
+
+func parseCalendarTime(value string, params map[string]string) (time.Time, bool, error) {
+	if params["VALUE"] == "DATE" || len(value) == 8 {
+		t, err := time.ParseInLocation("20060102", value, time.Local)
+		return t, true, err
+	}
+
+	if strings.HasSuffix(value, "Z") {
+		t, err := time.Parse("20060102T150405Z", value)
+		return t, false, err
+	}
+
+	loc := time.Local
+	if tzid, ok := params["TZID"]; ok {
+		if l, err := time.LoadLocation(tzid); err == nil {
+			loc = l
+		}
+	}
+	t, err := time.ParseInLocation("20060102T150405", value, loc)
+	return t, false, err
+}
+
+func parseCalendarDuration(value string) (time.Duration, error) {
+	negative := strings.HasPrefix(value, "-")
+	value = strings.TrimLeft(value, "+-")
+	if !strings.HasPrefix(value, "P") {
+		return 0, fmt.Errorf("invalid duration '%s'", value)
+	}
+
+	var duration time.Duration
+	inTime := false
+	number := ""
+	for _, c := range value[1:] {
+		switch {
+		case c == 'T':
+			inTime = true
+			continue
+		case c >= '0' && c <= '9':
+			number += string(c)
+			continue
+		}
+
+		n, err := strconv.Atoi(number)
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+		number = ""
+		switch {
+		case c == 'W':
+			duration += time.Duration(n) * 7 * 24 * time.Hour
+		case c == 'D':
+			duration += time.Duration(n) * 24 * time.Hour
+		case c == 'H' && inTime:
+			duration += time.Duration(n) * time.Hour
+		case c == 'M' && inTime:
+			duration += time.Duration(n) * time.Minute
+		case c == 'S' && inTime:
+			duration += time.Duration(n) * time.Second
+		default:
+			return 0, fmt.Errorf("invalid duration '%s'", value)
+		}
+	}
+
+	if negative {
+		duration = -duration
+	}
+	return duration, nil
+}
+
+func parseCalendar(path string) ([]calendarEvent, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	lines, err := unfoldLines(f)
+	if err != nil {
+		return nil, err
+	}
+
+	events := []calendarEvent{}
+	var event *calendarEvent
+	// Components nested inside an event, e.g. VALARM, are skipped
+	nested := 0
+	for _, line := range lines {
+		prop := parseProperty(line)
+		switch {
+		case prop.name == "BEGIN" && strings.ToUpper(prop.value) == "VEVENT":
+			event = &calendarEvent{}
+			continue
+		case prop.name == "END" && strings.ToUpper(prop.value) == "VEVENT":
+			if event != nil {
+				events = append(events, *event)
+			}
+			event = nil
+			continue
+		case event == nil:
+			continue
+		case prop.name == "BEGIN":
+			nested++
+			continue
+		case prop.name == "END":
+			nested--
+			continue
+		case nested > 0:
+			continue
+		}
+
+		switch prop.name {
+		case "UID":
+			event.uid = prop.value
+		case "SUMMARY":
+			event.summary = unescapeText(prop.value)
+		case "STATUS":
+			event.status = strings.ToUpper(prop.value)
+		case "DTSTART":
+			event.start, event.allDay, err = parseCalendarTime(prop.value, prop.params)
+		case "DTEND":
+			event.end, _, err = parseCalendarTime(prop.value, prop.params)
+		case "DURATION":
+			event.duration, err = parseCalendarDuration(prop.value)
+		case "RRULE":
+			event.rrule = prop.value
+		case "EXDATE":
+			for _, value := range strings.Split(prop.value, ",") {
+				var exdate time.Time
+				exdate, _, err = parseCalendarTime(value, prop.params)
+				if err != nil {
+					break
+				}
+				event.exdates = append(event.exdates, exdate)
+			}
+		case "RECURRENCE-ID":
+			event.recurrenceID, _, err = parseCalendarTime(prop.value, prop.params)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s in event '%s': %w", prop.name, event.summary, err)
+		}
+	}
+
+	return events, nil
+}
+
+func (e calendarEvent) length() time.Duration {
+	if !e.end.IsZero() {
+		return e.end.Sub(e.start)
+	}
+	return e.duration
+}
+
+type recurrenceRule struct {
+	freq       string
+	interval   int
+	count      int
+	until      time.Time
+	byDay      []string
+	byMonthDay []int
+}
+
+var calendarWeekdays = map[string]time.Weekday{
+	"SU": time.Sunday,
+	"MO": time.Monday,
+	"TU": time.Tuesday,
+	"WE": time.Wednesday,
+	"TH": time.Thursday,
+	"FR": time.Friday,
+	"SA": time.Saturday,
+}
+
+func parseRecurrenceRule(value string) (recurrenceRule, error) {
+	rule := recurrenceRule{interval: 1}
+	for _, part := range strings.Split(value, ";") {
+		key, val, _ := strings.Cut(part, "=")
+		var err error
+		switch strings.ToUpper(key) {
+		case "FREQ":
+			rule.freq = strings.ToUpper(val)
+		case "INTERVAL":
+			rule.interval, err = strconv.Atoi(val)
+		case "COUNT":
+			rule.count, err = strconv.Atoi(val)
+		case "UNTIL":
+			rule.until, _, err = parseCalendarTime(val, map[string]string{})
+			if err == nil && len(val) == 8 {
+				// Date-only UNTIL is inclusive of the whole day
+				rule.until = rule.until.Add(24*time.Hour - time.Second)
+			}
+		case "BYDAY":
+			rule.byDay = strings.Split(strings.ToUpper(val), ",")
+		case "BYMONTHDAY":
+			for _, day := range strings.Split(val, ",") {
+				var n int
+				n, err = strconv.Atoi(day)
+				if err != nil {
+					break
+				}
+				rule.byMonthDay = append(rule.byMonthDay, n)
+			}
+		case "WKST":
+		default:
+			return rule, fmt.Errorf("unsupported RRULE part '%s'", part)
+		}
+		if err != nil {
+			return rule, fmt.Errorf("invalid RRULE part '%s'", part)
+		}
+	}
+
+	switch rule.freq {
+	case "DAILY", "WEEKLY", "MONTHLY", "YEARLY":
+	default:
+		return rule, fmt.Errorf("unsupported RRULE frequency '%s'", rule.freq)
+	}
+	if rule.interval < 1 {
+		rule.interval = 1
+	}
+	return rule, nil
+}
+
+func splitByDay(byDay string) (int, time.Weekday, error) {
+	if len(byDay) < 2 {
+		return 0, 0, fmt.Errorf("invalid BYDAY value '%s'", byDay)
+	}
+	weekday, ok := calendarWeekdays[byDay[len(byDay)-2:]]
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid BYDAY value '%s'", byDay)
+	}
+	ordinal := 0
+	if len(byDay) > 2 {
+		n, err := strconv.Atoi(byDay[:len(byDay)-2])
+		if err != nil {
+			return 0, 0, fmt.Errorf("invalid BYDAY value '%s'", byDay)
+		}
+		ordinal = n
+	}
+	return ordinal, weekday, nil
+}
+
+func atTimeOfDay(day time.Time, start time.Time) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), start.Hour(),
+		start.Minute(), start.Second(), 0, start.Location())
+}
+
+// nthWeekday returns the nth given weekday of a month, counting from the end
+// of the month when n is negative, or every such weekday when n is zero.
+func nthWeekday(year int, month time.Month, weekday time.Weekday, n int, start time.Time) []time.Time {
+	days := []time.Time{}
+	for day := time.Date(year, month, 1, 0, 0, 0, 0, start.Location()); day.Month() == month; day = day.AddDate(0, 0, 1) {
+		if day.Weekday() == weekday {
+			days = append(days, atTimeOfDay(day, start))
+		}
+	}
+	if n == 0 {
+		return days
+	}
+	if n > 0 && n <= len(days) {
+		return days[n-1 : n]
+	}
+	if n < 0 && -n <= len(days) {
+		return days[len(days)+n : len(days)+n+1]
+	}
+	return []time.Time{}
+}
+
+// periodCandidates returns the candidate occurrence times within the ith
+// period (day, week, month or year) of the rule, in chronological order.
+func (rule recurrenceRule) periodCandidates(start time.Time, i int) ([]time.Time, error) {
+	candidates := []time.Time{}
+	switch rule.freq {
+	case "DAILY":
+		day := start.AddDate(0, 0, i*rule.interval)
+		if len(rule.byDay) == 0 {
+			return []time.Time{day}, nil
+		}
+		for _, byDay := range rule.byDay {
+			_, weekday, err := splitByDay(byDay)
+			if err != nil {
+				return nil, err
+			}
+			if day.Weekday() == weekday {
+				return []time.Time{day}, nil
+			}
+		}
+	case "WEEKLY":
+		// Weeks start on Monday
+		offset := (int(start.Weekday()) + 6) % 7
+		weekStart := start.AddDate(0, 0, i*7*rule.interval-offset)
+		if len(rule.byDay) == 0 {
+			return []time.Time{start.AddDate(0, 0, i*7*rule.interval)}, nil
+		}
+		for _, byDay := range rule.byDay {
+			_, weekday, err := splitByDay(byDay)
+			if err != nil {
+				return nil, err
+			}
+			candidates = append(candidates, weekStart.AddDate(0, 0, (int(weekday)+6)%7))
+		}
+	case "MONTHLY":
+		month := time.Date(start.Year(), start.Month()+time.Month(i*rule.interval), 1, 0, 0, 0, 0, start.Location())
+		daysInMonth := time.Date(month.Year(), month.Month()+1, 0, 0, 0, 0, 0, start.Location()).Day()
+		switch {
+		case len(rule.byMonthDay) > 0:
+			for _, n := range rule.byMonthDay {
+				if n < 0 {
+					n = daysInMonth + n + 1
+				}
+				if n >= 1 && n <= daysInMonth {
+					candidates = append(candidates, atTimeOfDay(month.AddDate(0, 0, n-1), start))
+				}
+			}
+		case len(rule.byDay) > 0:
+			for _, byDay := range rule.byDay {
+				ordinal, weekday, err := splitByDay(byDay)
+				if err != nil {
+					return nil, err
+				}
+				candidates = append(candidates, nthWeekday(month.Year(), month.Month(), weekday, ordinal, start)...)
+			}
+		case start.Day() <= daysInMonth:
+			candidates = append(candidates, atTimeOfDay(month.AddDate(0, 0, start.Day()-1), start))
+		}
+	case "YEARLY":
+		year := start.Year() + i*rule.interval
+		day := time.Date(year, start.Month(), start.Day(), start.Hour(), start.Minute(), start.Second(), 0, start.Location())
+		// Skip years where the date does not exist, e.g. 29th February
+		if day.Month() == start.Month() {
+			candidates = append(candidates, day)
+		}
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		return candidates[i].Before(candidates[j])
+	})
+	return candidates, nil
+}
+
+func isExcluded(t time.Time, exdates []time.Time) bool {
+	for _, exdate := range exdates {
+		if exdate.Equal(t) {
+			return true
+		}
+	}
+	return false
+}
+
+func (e calendarEvent) occurrences(windowStart time.Time, windowEnd time.Time) ([]occurrence, error) {
+	length := e.length()
+	if e.rrule == "" {
+		if e.start.Before(windowStart) || !e.start.Before(windowEnd) {
+			return []occurrence{}, nil
+		}
+		return []occurrence{{e.summary, e.start, e.start.Add(length)}}, nil
+	}
+
+	rule, err := parseRecurrenceRule(e.rrule)
+	if err != nil {
+		return nil, err
+	}
+
+	occurrences := []occurrence{}
+	count := 0
+	for i := 0; i < maxRecurrenceIterations; i++ {
+		candidates, err := rule.periodCandidates(e.start, i)
+		if err != nil {
+			return nil, err
+		}
+		for _, candidate := range candidates {
+			if candidate.Before(e.start) {
+				continue
+			}
+			if (!rule.until.IsZero() && candidate.After(rule.until)) ||
+				(rule.count > 0 && count >= rule.count) ||
+				!candidate.Before(windowEnd) {
+				return occurrences, nil
+			}
+			count++
+			if !isExcluded(candidate, e.exdates) && !candidate.Before(windowStart) {
+				occurrences = append(occurrences, occurrence{e.summary, candidate, candidate.Add(length)})
+			}
+		}
+	}
+	return occurrences, nil
+}
+
+// calendarOccurrences expands every event in the calendar whose summary
+// contains match into the individual occurrences that start within the date
+// window. Cancelled and all-day events are skipped.
+func calendarOccurrences(events []calendarEvent, match string, windowStart time.Time, windowEnd time.Time) ([]occurrence, error) {
+	// Modified instances of a recurring event replace the original occurrence
+	overridden := make(map[string][]time.Time)
+	for _, event := range events {
+		if !event.recurrenceID.IsZero() {
+			overridden[event.uid] = append(overridden[event.uid], event.recurrenceID)
+		}
+	}
+
+	match = strings.ToLower(match)
+	occurrences := []occurrence{}
+	for _, event := range events {
+		if event.start.IsZero() || event.allDay || event.status == "CANCELLED" {
+			continue
+		}
+		if !strings.Contains(strings.ToLower(event.summary), match) {
+			continue
+		}
+		if event.recurrenceID.IsZero() {
+			event.exdates = append(event.exdates, overridden[event.uid]...)
+		}
+
+		eventOccurrences, err := event.occurrences(windowStart, windowEnd)
+		if err != nil {
+			return nil, fmt.Errorf("event '%s': %w", event.summary, err)
+		}
+		occurrences = append(occurrences, eventOccurrences...)
+	}
+
+	sort.Slice(occurrences, func(i, j int) bool {
+		return occurrences[i].start.Before(occurrences[j].start)
+	})
+	return occurrences, nil
+}
+
+func ImportCalendar(db *sql.DB, path string, match string, name string, from time.Time, to time.Time) error {
+	events, err := parseCalendar(path)
+	if err != nil {
+		log.Printf("Error when reading calendar file: %s\n", err)
+		return err
+	}
+
+	// Window bounds are given as local dates
+	windowStart := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.Local)
+	windowEnd := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.Local).AddDate(0, 0, 1)
+
+	occurrences, err := calendarOccurrences(events, match, windowStart, windowEnd)
+	if err != nil {
+		log.Printf("Error when expanding calendar events: %s\n", err)
+		return err
+	}
+
+	now := time.Now()
+	imported := 0
+	duplicates := 0
+	for _, o := range occurrences {
+		// Only events that have already finished are completed sessions
+		if o.end.After(now) || !o.end.After(o.start) {
+			continue
+		}
+
+		session := Session{Name: o.summary, Start: wallClock(o.start), Finish: wallClock(o.end)}
+		if name != "" {
+			session.Name = name
+		}
+		if len(session.Name) > 100 {
+			session.Name = session.Name[:100]
+		}
+
+		exists, err := sessionExists(db, session)
+		if err != nil {
+			return err
+		}
+		if exists {
+			duplicates++
+			continue
+		}
+
+		_, err = insertSession(db, session, "import")
+		if err != nil {
+			return err
+		}
+		imported++
+	}
+
+	fmt.Printf(color.Ize(color.Green, "Imported %d sessions from %s\n"), imported, path)
+	if duplicates > 0 {
+		fmt.Printf(color.Ize(color.Yellow, "Skipped %d sessions already recorded\n"), duplicates)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package clockin
+
+import (
+	"testing"
+	"time"
+)
+
+func dateAt(year int, month time.Month, d int, hour int) time.Time {
+	return time.Date(year, month, d, hour, 0, 0, 0, time.UTC)
+}
+
+func TestOccurrences(t *testing.T) {
+	tests := []struct {
+		name  string
+		event calendarEvent
+		from  time.Time
+		to    time.Time
+		want  []time.Time
+	}{
+		{
+			name:  "single event in window",
+			event: calendarEvent{start: dateAt(2026, 9, 7, 9)},
+			from:  dateAt(2026, 9, 1, 0),
+			to:    dateAt(2026, 10, 1, 0),
+			want:  []time.Time{dateAt(2026, 9, 7, 9)},
+		},
+		{
+			name:  "single event before window",
+			event: calendarEvent{start: dateAt(2026, 8, 7, 9)},
+			from:  dateAt(2026, 9, 1, 0),
+			to:    dateAt(2026, 10, 1, 0),
+			want:  []time.Time{},
+		},
+		{
+			name:  "single event at window end",
+			event: calendarEvent{start: dateAt(2026, 10, 1, 0)},
+			from:  dateAt(2026, 9, 1, 0),
+			to:    dateAt(2026, 10, 1, 0),
+			want:  []time.Time{},
+		},
+		{
+			name:  "weekly on several days",
+			event: calendarEvent{start: dateAt(2026, 9, 7, 9), rrule: "FREQ=WEEKLY;BYDAY=MO,WE,FR;COUNT=5"},
+			from:  dateAt(2026, 9, 1, 0),
+			to:    dateAt(2026, 10, 1, 0),
+			want: []time.Time{dateAt(2026, 9, 7, 9), dateAt(2026, 9, 9, 9), dateAt(2026, 9, 11, 9),
+				dateAt(2026, 9, 14, 9), dateAt(2026, 9, 16, 9)},
+		},
+		{
+			name:  "monthly on the last Friday",
+			event: calendarEvent{start: dateAt(2026, 1, 30, 9), rrule: "FREQ=MONTHLY;BYDAY=-1FR;COUNT=3"},
+			from:  dateAt(2026, 1, 1, 0),
+			to:    dateAt(2026, 12, 1, 0),
+			want:  []time.Time{dateAt(2026, 1, 30, 9), dateAt(2026, 2, 27, 9), dateAt(2026, 3, 27, 9)},
+		},
+		{
+			name:  "monthly on the second Tuesday until a date",
+			event: calendarEvent{start: dateAt(2026, 1, 13, 9), rrule: "FREQ=MONTHLY;BYDAY=2TU;UNTIL=20260401"},
+			from:  dateAt(2026, 1, 1, 0),
+			to:    dateAt(2026, 12, 1, 0),
+			want:  []time.Time{dateAt(2026, 1, 13, 9), dateAt(2026, 2, 10, 9), dateAt(2026, 3, 10, 9)},
+		},
+		{
+			name:  "every other day within the window",
+			event: calendarEvent{start: dateAt(2026, 9, 1, 9), rrule: "FREQ=DAILY;INTERVAL=2"},
+			from:  dateAt(2026, 9, 5, 0),
+			to:    dateAt(2026, 9, 10, 0),
+			want:  []time.Time{dateAt(2026, 9, 5, 9), dateAt(2026, 9, 7, 9), dateAt(2026, 9, 9, 9)},
+		},
+		{
+			name: "excluded dates",
+			event: calendarEvent{start: dateAt(2026, 9, 7, 9), rrule: "FREQ=WEEKLY;COUNT=3",
+				exdates: []time.Time{dateAt(2026, 9, 14, 9)}},
+			from: dateAt(2026, 9, 1, 0),
+			to:   dateAt(2026, 10, 1, 0),
+			want: []time.Time{dateAt(2026, 9, 7, 9), dateAt(2026, 9, 21, 9)},
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.event.end = test.event.start.Add(time.Hour)
+			occurrences, err := test.event.occurrences(test.from, test.to)
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(occurrences) != len(test.want) {
+				t.Fatalf("got %d occurrences %v, want %v", len(occurrences), occurrences, test.want)
+			}
+			for i, o := range occurrences {
+				if !o.start.Equal(test.want[i]) || o.end.Sub(o.start) != time.Hour {
+					t.Errorf("occurrence %d is %s to %s, want %s for an hour", i, o.start, o.end, test.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestOccurrencesInvalidByDay(t *testing.T) {
+	for _, rrule := range []string{
+		"FREQ=WEEKLY;BYDAY=",
+		"FREQ=WEEKLY;BYDAY=MO,,TU",
+		"FREQ=WEEKLY;BYDAY=M",
+		"FREQ=WEEKLY;BYDAY=XX",
+		"FREQ=MONTHLY;BYDAY=AFR",
+	} {
+		event := calendarEvent{start: dateAt(2026, 9, 7, 9), end: dateAt(2026, 9, 7, 10), rrule: rrule}
+		if _, err := event.occurrences(dateAt(2026, 9, 1, 0), dateAt(2026, 10, 1, 0)); err == nil {
+			t.Errorf("%s: expected an error", rrule)
+		}
+	}
+}
@@ -0,0 +1,185 @@
+package clockin
+
+import (
+	"fmt"
+	"strings"
+)
+
+// keyBinding is an action of the stats page and the keys that trigger it.
+// Keys are termui event IDs, such as "q", "<Left>" or "<C-c>".
+type keyBinding struct {
+	action      string
+	description string
+	keys        []string
+}
+
+// keymap lists the actions of the stats page in the order they are shown in
+// the help overlay.
+type keymap []keyBinding
+
+// Number of tabs that can be jumped to with the tab1 to tab9 actions
+const tabJumps = 9
+
+var defaultKeymap = keymap{
+	{"left", "Previous tab", []string{"<Left>", "p", "l"}},
+	{"right", "Next tab", []string{"<Right>", "n", "r"}},
+	{"tab1", "Jump to a tab", []string{"1"}},
+	{"tab2", "Jump to a tab", []string{"2"}},
+	{"tab3", "Jump to a tab", []string{"3"}},
+	{"tab4", "Jump to a tab", []string{"4"}},
+	{"tab5", "Jump to a tab", []string{"5"}},
+	{"tab6", "Jump to a tab", []string{"6"}},
+	{"tab7", "Jump to a tab", []string{"7"}},
+	{"tab8", "Jump to a tab", []string{"8"}},
+	{"tab9", "Jump to a tab", []string{"9"}},
+	{"down", "Scroll down", []string{"j", "<Down>"}},
+	{"up", "Scroll up", []string{"k", "<Up>"}},
+	{"pageDown", "Scroll down a page", []string{"<PageDown>"}},
+	{"pageUp", "Scroll up a page", []string{"<PageUp>"}},
+	{"selectName", "Select the next name", []string{"<Tab>"}},
+	{"open", "Show the selected name's sessions", []string{"<Enter>"}},
+	{"dateRange", "Choose a date range", []string{"d"}},
+	{"search", "Search the Sessions tab", []string{"/"}},
+	{"filterName", "Show only the sessions with a name", []string{"F"}},
+	{"sort", "Sort by the next column", []string{"s"}},
+	{"reverseSort", "Reverse the sort order", []string{"S"}},
+	{"rename", "Rename the selected session", []string{"e"}},
+	{"editStart", "Change the selected session's start", []string{"a"}},
+	{"editFinish", "Change the selected session's finish", []string{"f"}},
+	{"stop", "Stop the selected session", []string{"t"}},
+	{"delete", "Delete the selected session", []string{"x"}},
+	{"help", "Show or hide this help", []string{"?"}},
+	{"back", "Close a view, or quit from the tabs", []string{"<Escape>"}},
+	{"quit", "Quit", []string{"q", "<C-c>"}},
+}
+
+// Keys changed from the default keymap by the vim keymap
+var vimKeys = map[string][]string{
+	"left":     {"<Left>", "h"},
+	"right":    {"<Right>", "l"},
+	"pageDown": {"<PageDown>", "<C-f>", "<C-d>"},
+	"pageUp":   {"<PageUp>", "<C-b>", "<C-u>"},
+}
+
+// rebind returns a copy of the keymap with the keys of some actions replaced.
+// Keys given to an action are taken away from any other action they were
+// bound to.
+func (k keymap) rebind(keys map[string][]string) (keymap, error) {
+	claimed := map[string]string{}
+	for action, actionKeys := range keys {
+		if _, ok := k.find(action); !ok {
+			return k, fmt.Errorf("unknown key action '%s'", action)
+		}
+		for _, key := range actionKeys {
+			if other, ok := claimed[key]; ok && other != action {
+				return k, fmt.Errorf("key '%s' is bound to both %s and %s", key, other, action)
+			}
+			claimed[key] = action
+		}
+	}
+
+	rebound := make(keymap, len(k))
+	for i, binding := range k {
+		if actionKeys, ok := keys[binding.action]; ok {
+			binding.keys = actionKeys
+		} else {
+			binding.keys = []string{}
+			for _, key := range k[i].keys {
+				if _, ok := claimed[key]; !ok {
+					binding.keys = append(binding.keys, key)
+				}
+			}
+		}
+		rebound[i] = binding
+	}
+	return rebound, nil
+}
+
+func (k keymap) find(action string) (keyBinding, bool) {
+	for _, binding := range k {
+		if binding.action == action {
+			return binding, true
+		}
+	}
+	return keyBinding{}, false
+}
+
+// actions returns the action triggered by each key.
+func (k keymap) actions() map[string]string {
+	actions := map[string]string{}
+	for _, binding := range k {
+		for _, key := range binding.keys {
+			actions[key] = binding.action
+		}
+	}
+	return actions
+}
+
+// keyLabel formats a termui event ID for display, such as "Left" for
+// "<Left>".
+func keyLabel(key string) string {
+	if len(key) > 2 && strings.HasPrefix(key, "<") && strings.HasSuffix(key, ">") {
+		return key[1 : len(key)-1]
+	}
+	return key
+}
+
+// label returns the first key of an action for display, or "-" if the action
+// has no keys.
+func (k keymap) label(action string) string {
+	binding, _ := k.find(action)
+	if len(binding.keys) == 0 {
+		return "-"
+	}
+	return keyLabel(binding.keys[0])
+}
+
+// help lists every action with its keys. The tab jumps share a line.
+func (k keymap) help() string {
+	lines := []string{}
+	jumps := []string{}
+	for _, binding := range k {
+		labels := make([]string, len(binding.keys))
+		for i, key := range binding.keys {
+			labels[i] = keyLabel(key)
+		}
+		if strings.HasPrefix(binding.action, "tab") {
+			jumps = append(jumps, strings.Join(labels, "/"))
+			if binding.action == fmt.Sprintf("tab%d", tabJumps) {
+				lines = append(lines, fmt.Sprintf("%-24s %s", strings.Join(jumps, " "), binding.description))
+			}
+			continue
+		}
+		lines = append(lines, fmt.Sprintf("%-24s %s", strings.Join(labels, ", "), binding.description))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// hints summarises the main keys for the bottom of the stats page.
+func (k keymap) hints() string {
+	return fmt.Sprintf("%s and %s switch tabs. %s selects a name and %s shows its sessions.\n"+
+		"%s chooses a date range, %s shows one name and %s searches the Sessions tab.\n"+
+		"Sessions: %s rename, %s start, %s finish, %s stop, %s delete, %s/%s sort.\n"+
+		"Press %s for all keys and %s to quit.",
+		k.label("left"), k.label("right"), k.label("selectName"), k.label("open"),
+		k.label("dateRange"), k.label("filterName"), k.label("search"),
+		k.label("rename"), k.label("editStart"), k.label("editFinish"), k.label("stop"), k.label("delete"),
+		k.label("sort"), k.label("reverseSort"), k.label("help"), k.label("quit"))
+}
+
+// loadKeymap returns the keymap chosen in the config, "default" or "vim",
+// with the keys of any actions changed by the keys setting.
+func loadKeymap(config Config) (keymap, error) {
+	k := defaultKeymap
+	switch strings.ToLower(config.Keymap) {
+	case "", "default":
+	case "vim":
+		var err error
+		if k, err = k.rebind(vimKeys); err != nil {
+			return k, err
+		}
+	default:
+		return k, fmt.Errorf("keymap must be default or vim, not '%s'", config.Keymap)
+	}
+	return k.rebind(config.Keys)
+}
@@ -0,0 +1,97 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestRebind(t *testing.T) {
+	tests := []struct {
+		name    string
+		keys    map[string][]string
+		want    map[string][]string
+		wantErr bool
+	}{
+		{
+			name: "no changes",
+			keys: nil,
+			want: map[string][]string{"quit": {"q", "<C-c>"}, "sort": {"s"}},
+		},
+		{
+			name: "new keys for an action",
+			keys: map[string][]string{"quit": {"Q"}},
+			want: map[string][]string{"quit": {"Q"}, "sort": {"s"}},
+		},
+		{
+			name: "key taken from another action",
+			keys: map[string][]string{"sort": {"q"}},
+			want: map[string][]string{"quit": {"<C-c>"}, "sort": {"q"}},
+		},
+		{
+			name: "keys swapped between actions",
+			keys: map[string][]string{"sort": {"S"}, "reverseSort": {"s"}},
+			want: map[string][]string{"sort": {"S"}, "reverseSort": {"s"}},
+		},
+		{
+			name: "action without keys",
+			keys: map[string][]string{"delete": {}},
+			want: map[string][]string{"delete": {}, "rename": {"e"}},
+		},
+		{
+			name:    "unknown action",
+			keys:    map[string][]string{"explode": {"x"}},
+			wantErr: true,
+		},
+		{
+			name:    "key given to two actions",
+			keys:    map[string][]string{"sort": {"o"}, "open": {"o"}},
+			wantErr: true,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rebound, err := defaultKeymap.rebind(test.keys)
+			if test.wantErr {
+				if err == nil {
+					t.Fatal("expected an error")
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("unexpected error: %s", err)
+			}
+			if len(rebound) != len(defaultKeymap) {
+				t.Fatalf("got %d actions, want %d", len(rebound), len(defaultKeymap))
+			}
+			for action, keys := range test.want {
+				binding, _ := rebound.find(action)
+				if !reflect.DeepEqual(binding.keys, keys) {
+					t.Errorf("%s is bound to %v, want %v", action, binding.keys, keys)
+				}
+			}
+		})
+	}
+}
+
+func TestRebindLeavesDefaultKeymap(t *testing.T) {
+	if _, err := defaultKeymap.rebind(map[string][]string{"sort": {"q"}}); err != nil {
+		t.Fatal(err)
+	}
+	if binding, _ := defaultKeymap.find("quit"); !reflect.DeepEqual(binding.keys, []string{"q", "<C-c>"}) {
+		t.Errorf("default quit keys changed to %v", binding.keys)
+	}
+}
+
+func TestLoadVimKeymap(t *testing.T) {
+	k, err := loadKeymap(Config{Keymap: "vim"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := k.actions()
+	for key, want := range map[string]string{"h": "left", "l": "right", "<C-d>": "pageDown", "q": "quit"} {
+		if actions[key] != want {
+			t.Errorf("%s triggers %q, want %q", key, actions[key], want)
+		}
+	}
+}
@@ -0,0 +1,120 @@
+package clockin
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"text/template"
+)
+
+type markdownSession struct {
+	ID       int
+	Name     string
+	Start    string
+	Finish   string
+	Duration string
+	Running  bool
+}
+
+type markdownDay struct {
+	Date     string
+	Total    string
+	Sessions []markdownSession
+}
+
+type markdownName struct {
+	Name       string
+	Sessions   int
+	Duration   string
+	Percentage string
+}
+
+// markdownReport is the data available to Markdown report templates.
+type markdownReport struct {
+	Title  string
+	Period string
+	Total  string
+	Days   []markdownDay
+	Names  []markdownName
+}
+
+const defaultMarkdownTemplate = `## {{.Title}}
+{{range .Days}}
+### {{.Date}} ({{.Total}})
+
+{{range .Sessions}}- {{.Name}}: {{if .Running}}running since {{.Start}}{{else}}{{.Duration}} ({{.Start}} - {{.Finish}}){{end}}
+{{end}}{{end}}
+### Totals
+
+{{range .Names}}- **{{.Name}}**: {{.Duration}} ({{.Percentage}})
+{{end}}- **Total**: {{.Total}}
+`
+
+func buildMarkdownReport(sessions []Session, description string) markdownReport {
+	total := totalDuration(sessions)
+	report := markdownReport{
+		Title:  "Work summary for " + description,
+		Period: description,
+		Total:  formatHours(total),
+	}
+
+	for _, group := range groupSessions(sessions, "day") {
+		day := markdownDay{
+			Date:  group.label,
+			Total: formatHours(totalDuration(group.sessions)),
+		}
+		for _, session := range group.sessions {
+			s := markdownSession{
+				ID:      session.ID,
+				Name:    displayName(session.Name),
+				Start:   session.Start.Format("15:04"),
+				Running: session.Finish.IsZero(),
+			}
+			if !s.Running {
+				s.Finish = session.Finish.Format("15:04")
+				s.Duration = formatHours(calcDuration(session))
+			}
+			day.Sessions = append(day.Sessions, s)
+		}
+		report.Days = append(report.Days, day)
+	}
+
+	nameTime := nameDurations(sessions)
+	count := countByName(sessions)
+	for _, name := range sortedNames(nameTime) {
+		report.Names = append(report.Names, markdownName{
+			Name:       displayName(name),
+			Sessions:   count[name],
+			Duration:   formatHours(nameTime[name]),
+			Percentage: formatPercentage(nameTime[name], total),
+		})
+	}
+	return report
+}
+
+// loadMarkdownTemplate parses the template at path, or the default template
+// if no path is given.
+func loadMarkdownTemplate(path string) (*template.Template, error) {
+	text := defaultMarkdownTemplate
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		text = string(data)
+	}
+
+	tmpl, err := template.New("markdown").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("invalid report template: %w", err)
+	}
+	return tmpl, nil
+}
+
+func writeMarkdownReport(w io.Writer, sessions []Session, description string, templatePath string) error {
+	tmpl, err := loadMarkdownTemplate(templatePath)
+	if err != nil {
+		return err
+	}
+	return tmpl.Execute(w, buildMarkdownReport(sessions, description))
+}
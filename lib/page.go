@@ -0,0 +1,523 @@
+package clockin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"image"
+	"math"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// DateRange is a period of time from Start (inclusive) to End (exclusive).
+// A zero Start has no lower bound, and a zero End is open-ended, including
+// sessions up to now.
+type DateRange struct {
+	Start time.Time
+	End   time.Time
+	Label string
+}
+
+// LastDays returns the range covering the given number of days up to now.
+func LastDays(days int) DateRange {
+	return DateRange{
+		Start: CurrentTime().AddDate(0, 0, -days),
+		Label: fmt.Sprintf("Last %dd", days),
+	}
+}
+
+// BetweenDates returns the range covering the days from and to, inclusive.
+// A zero from starts at the first session and a zero to ends today.
+func BetweenDates(from time.Time, to time.Time) DateRange {
+	r := DateRange{Start: from}
+	fromLabel := from.Format("2006-01-02")
+	if from.IsZero() {
+		fromLabel = "start"
+	}
+	toLabel := "now"
+	if !to.IsZero() {
+		r.End = to.AddDate(0, 0, 1)
+		toLabel = to.Format("2006-01-02")
+	}
+	r.Label = fromLabel + " to " + toLabel
+	return r
+}
+
+// parseDateRange parses a range entered in the stats page, either a number of
+// days such as "90d" or two dates such as "2026-09-01 2026-09-30".
+func parseDateRange(input string) (DateRange, error) {
+	fields := strings.Fields(strings.ReplaceAll(input, "..", " "))
+	switch len(fields) {
+	case 1:
+		days, err := ParseDays(fields[0])
+		if err != nil {
+			return DateRange{}, err
+		}
+		return LastDays(days), nil
+	case 2:
+		from, err := ParseDate(fields[0])
+		if err != nil {
+			return DateRange{}, err
+		}
+		to, err := ParseDate(fields[1])
+		if err != nil {
+			return DateRange{}, err
+		}
+		if to.Before(from) {
+			return DateRange{}, errors.New("end date is before start date")
+		}
+		return BetweenDates(from, to), nil
+	}
+	return DateRange{}, errors.New("enter a number of days, e.g. 90d, or two dates")
+}
+
+func (r DateRange) where() (string, []interface{}) {
+	conditions := []string{"deleted_at IS NULL"}
+	args := []interface{}{}
+	if !r.Start.IsZero() {
+		conditions = append(conditions, "start >= ?")
+		args = append(args, r.Start)
+	}
+	if !r.End.IsZero() {
+		conditions = append(conditions, "start < ?")
+		args = append(args, r.End)
+	}
+	return strings.Join(conditions, " AND "), args
+}
+
+// previous returns the range of the same length that ends where r starts, or
+// false if r has no start. Open-ended ranges are taken to end now.
+func (r DateRange) previous(now time.Time) (DateRange, bool) {
+	if r.Start.IsZero() {
+		return DateRange{}, false
+	}
+	end := r.End
+	if end.IsZero() {
+		end = now
+	}
+	start, end := previousPeriod(r.Start, end)
+	return DateRange{Start: start, End: end}, true
+}
+
+func startOfDay(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// Date ranges of the standard pages, relative to the current time
+func allTime(now time.Time) DateRange {
+	return DateRange{}
+}
+
+func today(now time.Time) DateRange {
+	start := startOfDay(now)
+	return DateRange{Start: start, End: start.AddDate(0, 0, 1)}
+}
+
+func last24Hours(now time.Time) DateRange {
+	return DateRange{Start: now.AddDate(0, 0, -1)}
+}
+
+func lastWeekRange(now time.Time) DateRange {
+	return DateRange{Start: now.AddDate(0, 0, -7)}
+}
+
+func lastMonth(now time.Time) DateRange {
+	return DateRange{Start: now.AddDate(0, -1, 0)}
+}
+
+func lastYear(now time.Time) DateRange {
+	return DateRange{Start: now.AddDate(-1, 0, 0)}
+}
+
+// pageData holds the sessions within a page's date range, and those within
+// the period of the same length before it for comparison. Pages without a
+// start, such as all time, have no previous period.
+type pageData struct {
+	sessions    []Session
+	previous    []Session
+	hasPrevious bool
+	// Bounds of the page's date range, with a zero start on pages covering
+	// all time
+	start time.Time
+	end   time.Time
+	// Name the sessions are restricted to, if any
+	name string
+}
+
+// widgetBuilder creates a widget from a page's sessions. Widgets made of
+// several components return them in a grid.
+type widgetBuilder func(page pageData) ui.Drawable
+
+// pageDefinition describes a stats page by the date range of sessions it
+// covers and the widgets it shows. The first widget is a summary shown above
+// the second, and any others are stacked beside them (see Page.layout). A
+// page without a date range browses every session instead.
+type pageDefinition struct {
+	title     string
+	dateRange func(now time.Time) DateRange
+	widgets   []widgetBuilder
+}
+
+var defaultPages = []pageDefinition{
+	{"All", allTime, []widgetBuilder{basicInfo, weekAverage, nameProportions, streaksPanel}},
+	{"Today", today, []widgetBuilder{basicInfo, sessionsTable, nameProportions}},
+	{"24hrs", last24Hours, []widgetBuilder{basicInfo, sessionsTable, nameProportions}},
+	{"Week", lastWeekRange, []widgetBuilder{basicInfo, lastWeek, nameProportions}},
+	{"Month", lastMonth, []widgetBuilder{basicInfo, weekAverage, nameProportions}},
+	{"Year", lastYear, []widgetBuilder{basicInfo, weekAverage, nameProportions, streaksPanel}},
+	{"Hours", lastYear, []widgetBuilder{basicInfo, hourOfDay, weekdayHours}},
+	{"Trend", trendRange, []widgetBuilder{basicInfo, trendChart}},
+	{"Heatmap", heatmapRange, []widgetBuilder{basicInfo, heatmapChart}},
+	{"Sessions", nil, nil},
+}
+
+// customPage defines a page for a fixed date range chosen by the user.
+func customPage(dateRange DateRange) pageDefinition {
+	return pageDefinition{
+		title: dateRange.Label,
+		dateRange: func(now time.Time) DateRange {
+			return dateRange
+		},
+		widgets: []widgetBuilder{basicInfo, weekAverage, nameProportions},
+	}
+}
+
+// Terminals narrower than this show the widgets of a page in one column
+const narrowWidth = 100
+
+// Height in lines of the summary widget at the top of a page
+const summaryHeight = 7
+
+type Page struct {
+	definition pageDefinition
+	data       pageData
+	components []ui.Drawable
+	// Area of the terminal the page is drawn in, and the grid laying out its
+	// components within it
+	area    image.Rectangle
+	grid    *ui.Grid
+	list    *widgets.List
+	table   *sessionTable
+	browser *sessionBrowser
+	names   []*nameLabel
+	// Only sessions with this name are shown when it is set
+	name string
+	// Index of the selected name label, or -1 if none is selected
+	selected int
+}
+
+func (p *Page) fetchSessions(db *sql.DB) error {
+	if p.browser != nil {
+		return p.browser.reload()
+	}
+	now := CurrentTime()
+	dateRange := p.definition.dateRange(now)
+	// Widgets that group sessions by day expect them in chronological order
+	where, args := p.where(dateRange)
+	sessions, err := querySessions(db, where+" ORDER BY start", args...)
+	if err != nil {
+		return err
+	}
+	end := dateRange.End
+	if end.IsZero() {
+		end = now
+	}
+	p.data = pageData{sessions: sessions, start: dateRange.Start, end: end, name: p.name}
+
+	if previous, ok := dateRange.previous(now); ok {
+		where, args = p.where(previous)
+		sessions, err = querySessions(db, where+" ORDER BY start", args...)
+		if err != nil {
+			return err
+		}
+		p.data.previous = sessions
+		p.data.hasPrevious = true
+	}
+	return nil
+}
+
+// where returns the conditions for sessions within a range, and with the
+// page's name if it has one.
+func (p *Page) where(r DateRange) (string, []interface{}) {
+	where, args := r.where()
+	if p.name != "" {
+		where += " AND name = ?"
+		args = append(args, p.name)
+	}
+	return where, args
+}
+
+// filterName restricts the page to sessions with a name, or shows every
+// session again if name is empty.
+func (p *Page) filterName(db *sql.DB, name string) error {
+	p.name = name
+	if p.browser != nil {
+		p.browser.name = name
+		return p.browser.reset()
+	}
+	if err := p.fetchSessions(db); err != nil {
+		return err
+	}
+	p.buildComponents()
+	return nil
+}
+
+func (p *Page) buildComponents() {
+	p.components = []ui.Drawable{}
+	p.list = nil
+	p.table = nil
+	p.names = nil
+	p.selected = -1
+	if p.browser != nil {
+		p.table = p.browser.sessionTable
+		p.components = append(p.components, p.browser)
+	}
+	for _, build := range p.definition.widgets {
+		component := build(p.data)
+		p.findInteractive(component)
+		p.components = append(p.components, component)
+	}
+	p.layout()
+}
+
+// findInteractive keeps the components that respond to keys, looking inside
+// widgets made of a grid of components.
+func (p *Page) findInteractive(component ui.Drawable) {
+	switch c := component.(type) {
+	case *ui.Grid:
+		for _, item := range c.Items {
+			if entry, ok := item.Entry.(ui.Drawable); ok {
+				p.findInteractive(entry)
+			}
+		}
+	case *chartLegend:
+		for _, label := range c.labels {
+			p.findInteractive(label)
+		}
+	case *widgets.List:
+		p.list = c
+	case *sessionTable:
+		p.table = c
+	case *nameLabel:
+		p.names = append(p.names, c)
+	}
+}
+
+// resize lays out the page again to fill area.
+func (p *Page) resize(area image.Rectangle) {
+	p.area = area
+	p.layout()
+}
+
+// layout arranges the components of the page in a grid filling its area.
+// The summary sits above the main widget on the left, with any other widgets
+// in a column on the right. The main widget takes the full width when there
+// are no others, and everything is stacked in one column in narrow
+// terminals.
+func (p *Page) layout() {
+	p.grid = ui.NewGrid()
+	p.grid.SetRect(p.area.Min.X, p.area.Min.Y, p.area.Max.X, p.area.Max.Y)
+	if len(p.components) == 0 || p.area.Dy() <= 0 {
+		return
+	}
+
+	narrow := p.area.Dx() < narrowWidth
+	side := p.components[1:]
+	if len(side) > 0 {
+		side = side[1:]
+	}
+	// Share of the height given to the summary and main widget, leaving the
+	// rest for the others when they are stacked below
+	mainShare := 1.0
+	if narrow && len(side) > 0 {
+		mainShare = 0.5
+	}
+	summaryShare := math.Min(float64(summaryHeight)/float64(p.area.Dy()), mainShare)
+
+	main := []interface{}{ui.NewRow(summaryShare, p.components[0])}
+	if len(p.components) > 1 {
+		main = append(main, ui.NewRow(mainShare-summaryShare, p.components[1]))
+	}
+	switch {
+	case len(p.components) == 1:
+		p.grid.Set(ui.NewRow(1, p.components[0]))
+	case len(side) == 0:
+		p.grid.Set(main...)
+	case narrow:
+		rows := main
+		for _, component := range side {
+			rows = append(rows, ui.NewRow((1-mainShare)/float64(len(side)), component))
+		}
+		p.grid.Set(rows...)
+	default:
+		rows := []interface{}{}
+		for _, component := range side {
+			rows = append(rows, ui.NewRow(1/float64(len(side)), component))
+		}
+		p.grid.Set(ui.NewCol(0.45, main...), ui.NewCol(0.55, rows...))
+	}
+}
+
+// selectNextName highlights the next name label of the pie chart, going back
+// to the first after the last.
+func (p *Page) selectNextName() {
+	if len(p.names) == 0 {
+		return
+	}
+	if p.selected != -1 {
+		p.names[p.selected].highlight(false)
+		ui.Render(p.names[p.selected])
+	}
+	p.selected = (p.selected + 1) % len(p.names)
+	p.names[p.selected].highlight(true)
+	ui.Render(p.names[p.selected])
+}
+
+// selectedName returns the name of the selected label, or false if no label
+// is selected.
+func (p *Page) selectedName() (string, bool) {
+	if p.selected == -1 {
+		return "", false
+	}
+	return p.names[p.selected].name, true
+}
+
+// refresh fetches the page's sessions again and rebuilds its widgets, keeping
+// the selected name and the sort order and selected row of its sessions
+// table. The widgets are left as they were if the sessions cannot be
+// fetched.
+func (p *Page) refresh(db *sql.DB) error {
+	if p.browser != nil {
+		return p.fetchSessions(db)
+	}
+	var state *sessionTableState
+	if p.table != nil {
+		s := p.table.state()
+		state = &s
+	}
+	selectedName, nameSelected := p.selectedName()
+
+	if err := p.fetchSessions(db); err != nil {
+		return err
+	}
+	p.buildComponents()
+	if state != nil && p.table != nil {
+		p.table.restore(*state)
+	}
+	if nameSelected {
+		for i, label := range p.names {
+			if label.name == selectedName {
+				p.selected = i
+				label.highlight(true)
+			}
+		}
+	}
+	return nil
+}
+
+// scroll moves the selected row of a sessions table, or pages through a list.
+// direction is "up" or "down", or "page up" or "page down" to move a table a
+// page at a time. An error is returned if the Sessions tab cannot fetch the
+// sessions it moves to.
+func (p *Page) scroll(direction string) error {
+	if p.browser != nil {
+		if err := p.browser.move(p.scrollDelta(direction)); err != nil {
+			return err
+		}
+		ui.Render(p.browser)
+	} else if p.table != nil {
+		p.table.move(p.scrollDelta(direction))
+		ui.Render(p.table)
+	} else if p.list != nil {
+		if strings.HasSuffix(direction, "up") {
+			p.list.ScrollPageUp()
+			ui.Render(p.list)
+		} else if strings.HasSuffix(direction, "down") {
+			p.list.ScrollPageDown()
+			ui.Render(p.list)
+		}
+	}
+	return nil
+}
+
+func (p *Page) scrollDelta(direction string) int {
+	switch direction {
+	case "up":
+		return -1
+	case "down":
+		return 1
+	case "page up":
+		return -p.table.visibleRows()
+	case "page down":
+		return p.table.visibleRows()
+	}
+	return 0
+}
+
+// sort sorts the page's sessions table by the next column, or reverses its
+// order. The Sessions tab fetches its sessions again in the new order.
+func (p *Page) sort(reverse bool) error {
+	var err error
+	switch {
+	case p.browser != nil && reverse:
+		err = p.browser.reverseSort()
+	case p.browser != nil:
+		err = p.browser.nextSortColumn()
+	case p.table != nil && reverse:
+		p.table.reverseSort()
+		ui.Render(p.table)
+	case p.table != nil:
+		p.table.nextSortColumn()
+		ui.Render(p.table)
+	}
+	if err != nil {
+		return err
+	}
+	if p.browser != nil {
+		ui.Render(p.browser)
+	}
+	return nil
+}
+
+func (p *Page) render() {
+	ui.Render(p.grid)
+}
+
+// buildPage fetches the sessions of a page, restricted to those with name if
+// it is not empty.
+func buildPage(db *sql.DB, definition pageDefinition, name string) (*Page, error) {
+	page := Page{definition: definition, name: name}
+	if definition.dateRange == nil {
+		page.browser = newSessionBrowser(db)
+		page.browser.name = name
+	}
+	if err := page.fetchSessions(db); err != nil {
+		return nil, err
+	}
+	page.buildComponents()
+	return &page, nil
+}
+
+func buildPages(db *sql.DB, definitions []pageDefinition, name string) ([]*Page, error) {
+	pages := make([]*Page, len(definitions))
+	for i, definition := range definitions {
+		page, err := buildPage(db, definition, name)
+		if err != nil {
+			return nil, err
+		}
+		pages[i] = page
+	}
+	return pages, nil
+}
+
+func pageTitles(pages []*Page) []string {
+	titles := make([]string, len(pages))
+	for i, page := range pages {
+		titles[i] = page.definition.title
+	}
+	return titles
+}
@@ -0,0 +1,251 @@
+package clockin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/TwiN/go-color"
+)
+
+// ReportOptions selects the sessions included in a report, how they are
+// grouped and the format to write. From and To are only used by the custom
+// period, and the report is written to stdout if Output is empty. Markdown
+// reports use the reportTemplate from the config if Template is empty.
+// Weekdays splits reports grouped by hour into a column for each weekday.
+type ReportOptions struct {
+	Period   string
+	From     time.Time
+	To       time.Time
+	GroupBy  string
+	Weekdays bool
+	Format   string
+	Output   string
+	Template string
+}
+
+// reportRange returns the start (inclusive) and end (exclusive) of the period
+// covered by a report, along with a description of the period.
+func reportRange(options ReportOptions) (time.Time, time.Time, string, error) {
+	now := CurrentTime()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	period := options.Period
+	if !options.From.IsZero() || !options.To.IsZero() {
+		// Dates on their own give a custom period
+		if period == "" {
+			period = "custom"
+		} else if period != "custom" {
+			return now, now, "", fmt.Errorf("--from and --to cannot be used with period '%s'", period)
+		}
+	}
+	switch period {
+	case "today":
+		return today, today.AddDate(0, 0, 1), "today", nil
+	case "", "week":
+		return now.AddDate(0, 0, -7), now, "the last 7 days", nil
+	case "month":
+		return now.AddDate(0, -1, 0), now, "the last month", nil
+	case "year":
+		return now.AddDate(-1, 0, 0), now, "the last year", nil
+	case "all":
+		return time.Time{}, now, "all time", nil
+	case "custom":
+		if options.From.IsZero() || options.To.IsZero() {
+			return now, now, "", errors.New("custom period requires --from and --to dates")
+		}
+		// The end date is inclusive
+		to := options.To.AddDate(0, 0, 1)
+		description := fmt.Sprintf("%s to %s", options.From.Format("2006-01-02"), options.To.Format("2006-01-02"))
+		return options.From, to, description, nil
+	}
+	return now, now, "", fmt.Errorf("unknown period '%s'", period)
+}
+
+func reportSessions(db *sql.DB, start time.Time, end time.Time) ([]Session, error) {
+	return querySessions(db, "deleted_at IS NULL AND start >= ? AND start < ? ORDER BY start", start, end)
+}
+
+type reportGroup struct {
+	label    string
+	sessions []Session
+}
+
+func isoWeek(t time.Time) string {
+	year, week := t.ISOWeek()
+	return fmt.Sprintf("%d-W%02d", year, week)
+}
+
+// groupSessions splits sessions into groups labelled by their start day or
+// week, in chronological order.
+func groupSessions(sessions []Session, groupBy string) []reportGroup {
+	groups := []reportGroup{}
+	index := make(map[string]int)
+	for _, session := range sessions {
+		var label string
+		if groupBy == "week" {
+			label = isoWeek(session.Start)
+		} else {
+			label = session.Start.Format("2006-01-02 Mon")
+		}
+		if _, ok := index[label]; !ok {
+			index[label] = len(groups)
+			groups = append(groups, reportGroup{label: label})
+		}
+		groups[index[label]].sessions = append(groups[index[label]].sessions, session)
+	}
+	return groups
+}
+
+func countByName(sessions []Session) map[string]int {
+	count := make(map[string]int)
+	for _, session := range sessions {
+		if !session.Finish.IsZero() {
+			count[session.Name]++
+		}
+	}
+	return count
+}
+
+// sortedNames returns the names in a name to duration map, longest duration
+// first.
+func sortedNames(nameTime map[string]time.Duration) []string {
+	names := make([]string, 0, len(nameTime))
+	for name := range nameTime {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		if nameTime[names[i]] != nameTime[names[j]] {
+			return nameTime[names[i]] > nameTime[names[j]]
+		}
+		return names[i] < names[j]
+	})
+	return names
+}
+
+func numCompleted(sessions []Session) int {
+	return len(sessions) - numActive(sessions)
+}
+
+func nameRows(table *textTable, sessions []Session, total time.Duration, indent bool) {
+	nameTime := nameDurations(sessions)
+	count := countByName(sessions)
+	for _, name := range sortedNames(nameTime) {
+		row := []string{displayName(name)}
+		if indent {
+			row = []string{"", displayName(name)}
+		}
+		row = append(row, fmt.Sprintf("%d", count[name]), formatHours(nameTime[name]),
+			formatPercentage(nameTime[name], total))
+		table.addRow(row...)
+	}
+}
+
+func buildReportTable(sessions []Session, options ReportOptions) (textTable, error) {
+	total := totalDuration(sessions)
+	groupBy := options.GroupBy
+	var table textTable
+	switch groupBy {
+	case "", "name":
+		table = textTable{
+			headers:    []string{"Name", "Sessions", "Duration", "Share"},
+			alignRight: []bool{false, true, true, true},
+		}
+		nameRows(&table, sessions, total, false)
+		table.addSeparator()
+		table.addRow("Total", fmt.Sprintf("%d", numCompleted(sessions)), formatHours(total), formatPercentage(total, total))
+	case "day", "week":
+		header := "Day"
+		if groupBy == "week" {
+			header = "Week"
+		}
+		table = textTable{
+			headers:    []string{header, "Name", "Sessions", "Duration", "Share"},
+			alignRight: []bool{false, false, true, true, true},
+		}
+		for _, group := range groupSessions(sessions, groupBy) {
+			subtotal := totalDuration(group.sessions)
+			table.addRow(group.label, "", fmt.Sprintf("%d", numCompleted(group.sessions)),
+				formatHours(subtotal), formatPercentage(subtotal, total))
+			nameRows(&table, group.sessions, total, true)
+		}
+		table.addSeparator()
+		table.addRow("Total", "", fmt.Sprintf("%d", numCompleted(sessions)), formatHours(total), formatPercentage(total, total))
+	case "hour":
+		table = buildHourTable(sessions, options.Weekdays)
+	case "tag":
+		return table, errors.New("sessions do not have tags to group by")
+	default:
+		return table, fmt.Errorf("unknown grouping '%s'", groupBy)
+	}
+	return table, nil
+}
+
+func writeTextReport(w io.Writer, sessions []Session, options ReportOptions, description string) error {
+	table, err := buildReportTable(sessions, options)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprintf(w, "Report for %s\n\n", description)
+	table.render(w)
+	if active := numActive(sessions); active > 0 {
+		fmt.Fprintf(w, "\n%d running sessions are not included in the totals\n", active)
+	}
+	return nil
+}
+
+// Report writes a summary of the time recorded within a period as a text
+// table, a self-contained HTML page or Markdown, without the need for an
+// interactive terminal.
+func Report(db *sql.DB, options ReportOptions) error {
+	start, end, description, err := reportRange(options)
+	if err != nil {
+		return err
+	}
+
+	sessions, err := reportSessions(db, start, end)
+	if err != nil {
+		return err
+	}
+
+	w := os.Stdout
+	if options.Output != "" {
+		f, err := os.Create(options.Output)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		w = f
+	}
+
+	switch options.Format {
+	case "", "text":
+		err = writeTextReport(w, sessions, options, description)
+	case "html":
+		err = writeHTMLReport(w, sessions, start, end, description)
+	case "markdown", "md":
+		templatePath := options.Template
+		if templatePath == "" {
+			config, err := LoadConfig()
+			if err != nil {
+				return err
+			}
+			templatePath = config.ReportTemplate
+		}
+		err = writeMarkdownReport(w, sessions, description, templatePath)
+	default:
+		err = fmt.Errorf("unknown format '%s'", options.Format)
+	}
+	if err != nil {
+		return err
+	}
+
+	if options.Output != "" {
+		fmt.Printf(color.Ize(color.Green, "Report written to %s\n"), options.Output)
+	}
+	return nil
+}
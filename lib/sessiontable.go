@@ -0,0 +1,212 @@
+package clockin
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+var sessionTableHeaders = []string{"ID", "Name", "Start", "Finish", "Duration"}
+
+// sessionTable is a table of sessions that can be sorted by any column, with
+// a selected row that can be edited from the stats page.
+type sessionTable struct {
+	*widgets.Table
+	sessions   []Session
+	sortColumn int
+	descending bool
+	selected   int
+	// Index of the first session shown, when there are more sessions than
+	// fit in the table
+	offset int
+}
+
+// sessionTableState is kept by a page so that the sort order and selected
+// session survive the table being rebuilt.
+type sessionTableState struct {
+	sortColumn int
+	descending bool
+	selectedID int
+}
+
+func sessionFinish(session Session, now time.Time) time.Time {
+	if session.Finish.IsZero() {
+		return now
+	}
+	return session.Finish
+}
+
+func (t *sessionTable) sort() {
+	now := CurrentTime()
+	sort.SliceStable(t.sessions, func(i, j int) bool {
+		a, b := t.sessions[i], t.sessions[j]
+		if t.descending {
+			a, b = b, a
+		}
+		switch t.sortColumn {
+		case 1:
+			return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+		case 2:
+			return a.Start.Before(b.Start)
+		case 3:
+			return sessionFinish(a, now).Before(sessionFinish(b, now))
+		case 4:
+			return sessionFinish(a, now).Sub(a.Start) < sessionFinish(b, now).Sub(b.Start)
+		}
+		return a.ID < b.ID
+	})
+}
+
+// visibleRows returns the number of sessions that fit below the header. At
+// least one row is assumed before the table has been laid out.
+func (t *sessionTable) visibleRows() int {
+	if t.Inner.Dy() < 2 {
+		return 1
+	}
+	return t.Inner.Dy() - 1
+}
+
+// scrollToSelected moves the offset so that the selected session is in view,
+// which can change when the table is resized.
+func (t *sessionTable) scrollToSelected() {
+	if t.selected < t.offset {
+		t.offset = t.selected
+	} else if t.selected >= t.offset+t.visibleRows() {
+		t.offset = t.selected - t.visibleRows() + 1
+	}
+}
+
+// update fills the rows of the table from the sessions that are scrolled
+// into view.
+func (t *sessionTable) update() {
+	header := make([]string, len(sessionTableHeaders))
+	copy(header, sessionTableHeaders)
+	if t.descending {
+		header[t.sortColumn] += " ▼"
+	} else {
+		header[t.sortColumn] += " ▲"
+	}
+
+	now := CurrentTime()
+	t.Rows = [][]string{header}
+	t.RowStyles = map[int]ui.Style{0: ui.NewStyle(activeTheme.label, ui.ColorClear, ui.ModifierBold)}
+	for i := t.offset; i < len(t.sessions) && i < t.offset+t.visibleRows(); i++ {
+		session := t.sessions[i]
+		finish := "running"
+		if !session.Finish.IsZero() {
+			finish = session.Finish.Format("15:04")
+			if startOfDay(session.Finish) != startOfDay(session.Start) {
+				finish = session.Finish.Format("01-02 15:04")
+			}
+		}
+		t.Rows = append(t.Rows, []string{strconv.Itoa(session.ID), displayName(session.Name),
+			session.Start.Format("01-02 15:04"), finish, formatHours(sessionFinish(session, now).Sub(session.Start))})
+		if i == t.selected {
+			t.RowStyles[len(t.Rows)-1] = activeTheme.selected
+		} else if session.Finish.IsZero() {
+			t.RowStyles[len(t.Rows)-1] = ui.NewStyle(activeTheme.active)
+		}
+	}
+}
+
+// Draw fills the rows again before drawing, as the number that fit depends
+// on the size given to the table by the page layout.
+func (t *sessionTable) Draw(buf *ui.Buffer) {
+	t.scrollToSelected()
+	t.update()
+	t.Table.Draw(buf)
+}
+
+// move changes the selected session by delta rows, scrolling to keep it in
+// view.
+func (t *sessionTable) move(delta int) {
+	if len(t.sessions) == 0 {
+		return
+	}
+	t.selected += delta
+	if t.selected < 0 {
+		t.selected = 0
+	} else if t.selected >= len(t.sessions) {
+		t.selected = len(t.sessions) - 1
+	}
+	t.scrollToSelected()
+	t.update()
+}
+
+// sortBy sorts the sessions by a column, keeping the same session selected.
+func (t *sessionTable) sortBy(column int, descending bool) {
+	selected, ok := t.selectedSession()
+	t.sortColumn = column
+	t.descending = descending
+	t.sort()
+	t.offset = 0
+	t.selected = 0
+	if ok {
+		t.selectID(selected.ID)
+	}
+	t.update()
+}
+
+// nextSortColumn sorts by the column to the right of the current one.
+func (t *sessionTable) nextSortColumn() {
+	t.sortBy((t.sortColumn+1)%len(sessionTableHeaders), t.descending)
+}
+
+func (t *sessionTable) reverseSort() {
+	t.sortBy(t.sortColumn, !t.descending)
+}
+
+func (t *sessionTable) selectID(id int) {
+	for i, session := range t.sessions {
+		if session.ID == id {
+			t.move(i - t.selected)
+			return
+		}
+	}
+}
+
+func (t *sessionTable) selectedSession() (Session, bool) {
+	if t.selected < 0 || t.selected >= len(t.sessions) {
+		return Session{}, false
+	}
+	return t.sessions[t.selected], true
+}
+
+func (t *sessionTable) state() sessionTableState {
+	selected, _ := t.selectedSession()
+	return sessionTableState{sortColumn: t.sortColumn, descending: t.descending, selectedID: selected.ID}
+}
+
+func (t *sessionTable) restore(state sessionTableState) {
+	t.sortColumn = state.sortColumn
+	t.descending = state.descending
+	t.sort()
+	t.selectID(state.selectedID)
+	t.update()
+}
+
+// newSessionTable returns a table of sessions sorted by the given column.
+func newSessionTable(sessions []Session, sortColumn int, descending bool) *sessionTable {
+	t := &sessionTable{
+		Table:      widgets.NewTable(),
+		sessions:   sessions,
+		sortColumn: sortColumn,
+		descending: descending,
+	}
+	t.Title = "Sessions"
+	t.RowSeparator = false
+	t.FillRow = true
+	t.ColumnWidths = []int{5, 16, 11, 11, 9}
+	return t
+}
+
+func sessionsTable(page pageData) ui.Drawable {
+	t := newSessionTable(append([]Session{}, page.sessions...), 2, false)
+	t.sort()
+	t.update()
+	return t
+}
@@ -0,0 +1,81 @@
+package clockin
+
+import (
+	"database/sql"
+	"fmt"
+	"image"
+	"strings"
+
+	ui "github.com/gizak/termui/v3"
+)
+
+// Size of each tab printed by stats --print, unless a width is given
+const (
+	snapshotWidth  = 120
+	snapshotHeight = 30
+)
+
+// renderText draws a widget into a buffer of the given size and returns the
+// buffer as plain text, without trailing spaces or blank lines. Blank cells
+// with a background colour, such as the bars of bar charts, become blocks.
+func renderText(d ui.Drawable, width int, height int) string {
+	buf := ui.NewBuffer(image.Rect(0, 0, width, height))
+	buf.Fill(ui.NewCell(' '), buf.Rectangle)
+	d.Draw(buf)
+
+	lines := make([]string, height)
+	for y := 0; y < height; y++ {
+		var line strings.Builder
+		for x := 0; x < width; x++ {
+			cell := buf.GetCell(image.Pt(x, y))
+			if cell.Rune == ' ' && cell.Style.Bg != ui.ColorClear {
+				cell.Rune = '█'
+			}
+			line.WriteRune(cell.Rune)
+		}
+		lines[y] = strings.TrimRight(line.String(), " ")
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
+
+// PrintStats prints the tabs of the stats page once as plain text, without
+// taking over the terminal. Only the tab whose title matches options.Tab is
+// printed if it is set. The monochrome theme is used, so that heatmap levels
+// are told apart by shading.
+func PrintStats(db *sql.DB, options StatsOptions) error {
+	activeTheme = themes["monochrome"]
+	width := options.Width
+	if width == 0 {
+		width = snapshotWidth
+	}
+
+	definitions := defaultPages
+	if !options.Range.Start.IsZero() || !options.Range.End.IsZero() {
+		definitions = append(append([]pageDefinition{}, defaultPages...), customPage(options.Range))
+	}
+	if options.Tab != "" {
+		matching := []pageDefinition{}
+		for _, definition := range definitions {
+			if strings.EqualFold(definition.title, options.Tab) {
+				matching = append(matching, definition)
+			}
+		}
+		if len(matching) == 0 {
+			return fmt.Errorf("no stats tab named '%s'", options.Tab)
+		}
+		definitions = matching
+	}
+
+	for i, definition := range definitions {
+		page, err := buildPage(db, definition, options.Name)
+		if err != nil {
+			return err
+		}
+		page.resize(image.Rect(0, 0, width, snapshotHeight))
+		if i > 0 {
+			fmt.Println()
+		}
+		fmt.Printf("%s\n\n%s\n", definition.title, renderText(page.grid, width, snapshotHeight))
+	}
+	return nil
+}
@@ -0,0 +1,103 @@
+package clockin
+
+import (
+	"flag"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+// snapshotSessions returns sessions over the week before 18 October 2026,
+// with one still running at noon that day, and one the week before.
+func snapshotSessions() []Session {
+	session := func(id int, name string, daysAgo int, hour int, length time.Duration) Session {
+		start := dateAt(2026, 10, 18-daysAgo, hour)
+		return Session{ID: id, Name: name, Start: start, Finish: start.Add(length)}
+	}
+	return []Session{
+		session(1, "writing", 6, 9, 3*time.Hour),
+		session(2, "reading", 5, 14, 90*time.Minute),
+		session(3, "writing", 4, 9, 4*time.Hour),
+		session(4, "exercise", 4, 18, time.Hour),
+		session(5, "writing", 2, 10, 2*time.Hour),
+		session(6, "", 1, 20, 30*time.Minute),
+		session(7, "reading", 0, 8, 2*time.Hour),
+		{ID: 8, Name: "writing", Start: dateAt(2026, 10, 18, 11)},
+		session(9, "writing", 10, 9, 4*time.Hour),
+	}
+}
+
+func TestRenderTextGolden(t *testing.T) {
+	clock = func() time.Time { return dateAt(2026, 10, 18, 12) }
+	defer func() { clock = time.Now }()
+	previousTheme := activeTheme
+	activeTheme = themes["monochrome"]
+	activeTheme.apply()
+	defer func() {
+		activeTheme = previousTheme
+		activeTheme.apply()
+	}()
+
+	sessions := snapshotSessions()
+	// within picks the sessions a page would fetch for a date range
+	within := func(r DateRange) []Session {
+		matching := []Session{}
+		for _, session := range sessions {
+			if (r.Start.IsZero() || !session.Start.Before(r.Start)) && (r.End.IsZero() || session.Start.Before(r.End)) {
+				matching = append(matching, session)
+			}
+		}
+		return matching
+	}
+
+	for _, test := range []struct {
+		tab    string
+		golden string
+	}{
+		{tab: "Today", golden: "today.golden"},
+		{tab: "Week", golden: "week.golden"},
+	} {
+		t.Run(test.tab, func(t *testing.T) {
+			var definition pageDefinition
+			for _, d := range defaultPages {
+				if d.title == test.tab {
+					definition = d
+				}
+			}
+			dateRange := definition.dateRange(CurrentTime())
+			previousRange, hasPrevious := dateRange.previous(CurrentTime())
+			end := dateRange.End
+			if end.IsZero() {
+				end = CurrentTime()
+			}
+			page := Page{definition: definition, data: pageData{
+				sessions:    within(dateRange),
+				previous:    within(previousRange),
+				hasPrevious: hasPrevious,
+				start:       dateRange.Start,
+				end:         end,
+			}}
+			page.buildComponents()
+			page.resize(image.Rect(0, 0, snapshotWidth, snapshotHeight))
+			got := renderText(page.grid, snapshotWidth, snapshotHeight) + "\n"
+
+			path := filepath.Join("testdata", test.golden)
+			if *update {
+				if err := os.WriteFile(path, []byte(got), 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatalf("%s (run go test with -update to create it)", err)
+			}
+			if got != string(want) {
+				t.Errorf("%s tab does not match %s:\n%s", test.tab, path, got)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package clockin
 import (
 	"database/sql"
 	"fmt"
+	"image"
 	"log"
 	"math"
 	"sort"
+	"strings"
 	"time"
+	"unicode/utf8"
 
 	ui "github.com/gizak/termui/v3"
 	"github.com/gizak/termui/v3/widgets"
@@ -43,59 +46,14 @@ func ExtractSessions(rows *sql.Rows) []Session {
 }
 
 func getSessions(db *sql.DB, includeActive bool, sqlDateRange string) ([]Session, error) {
-	query := "SELECT * FROM clockin"
+	conditions := []string{"deleted_at IS NULL"}
 	if !includeActive {
-		query += " WHERE FINISH IS NOT NULL"
-		if sqlDateRange != "" {
-			query += " AND " + sqlDateRange
-		}
-	} else if sqlDateRange != "" {
-		query += " WHERE " + sqlDateRange
+		conditions = append(conditions, "FINISH IS NOT NULL")
 	}
-
-	rows, err := db.Query(query)
-	if err != nil {
-		return nil, err
+	if sqlDateRange != "" {
+		conditions = append(conditions, sqlDateRange)
 	}
-
-	sessions := ExtractSessions(rows)
-	return sessions, nil
-}
-
-func (a *All) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "")
-	Check(err)
-	a.sessions = sessions
-}
-
-func (t *Today) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "start BETWEEN CONCAT(CURDATE(), ' 00:00:00') AND CONCAT(CURDATE(), ' 23:59:59')")
-	Check(err)
-	t.sessions = sessions
-}
-
-func (d *Day) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "start >= DATE_SUB(NOW(), INTERVAL 1 DAY)")
-	Check(err)
-	d.sessions = sessions
-}
-
-func (w *Week) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "start >= DATE_SUB(NOW(), INTERVAL 1 WEEK)")
-	Check(err)
-	w.sessions = sessions
-}
-
-func (m *Month) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "start >= DATE_SUB(NOW(), INTERVAL 1 MONTH)")
-	Check(err)
-	m.sessions = sessions
-}
-
-func (y *Year) fetchSessions(db *sql.DB) {
-	sessions, err := getSessions(db, true, "start >= DATE_SUB(NOW(), INTERVAL 1 YEAR)")
-	Check(err)
-	y.sessions = sessions
+	return querySessions(db, strings.Join(conditions, " AND "))
 }
 
 func numActive(sessions []Session) int {
@@ -108,31 +66,41 @@ func numActive(sessions []Session) int {
 	return count
 }
 
-func basicInfo(sessions []Session) (*widgets.Paragraph, *widgets.Paragraph,
-	*widgets.Paragraph) {
+func basicInfo(page pageData) ui.Drawable {
 	p := widgets.NewParagraph()
-	duration := totalDuration(sessions)
-	p.TextStyle = ui.NewStyle(ui.ColorGreen)
+	duration := totalDuration(page.sessions)
+	p.TextStyle = ui.NewStyle(activeTheme.text)
 	p.Title = "Total duration"
+	if page.name != "" {
+		p.Title += " of " + displayName(page.name)
+	}
 	p.Text = formatDuration(duration, 3)
+	if page.hasPrevious {
+		p.Text += "  " + changeMarkup(duration, totalDuration(page.previous)) + " on previous period"
+	}
 	p.PaddingLeft = 2
-	p.SetRect(0, 4, 61, 7)
 
 	p2 := widgets.NewParagraph()
-	p2.TextStyle = ui.NewStyle(ui.ColorGreen)
+	p2.TextStyle = ui.NewStyle(activeTheme.text)
 	p2.Title = "Completed"
-	p2.Text = fmt.Sprintf("%d", len(sessions))
+	p2.Text = fmt.Sprintf("%d", numCompleted(page.sessions))
+	if page.hasPrevious {
+		p2.Text += fmt.Sprintf(" (%+d)", numCompleted(page.sessions)-numCompleted(page.previous))
+	}
 	p2.PaddingLeft = 2
-	p2.SetRect(0, 7, 30, 10)
 
 	p3 := widgets.NewParagraph()
-	p3.TextStyle = ui.NewStyle(ui.ColorYellow)
+	p3.TextStyle = ui.NewStyle(activeTheme.active)
 	p3.Title = "Active"
-	p3.Text = fmt.Sprintf("%d", numActive(sessions))
+	p3.Text = fmt.Sprintf("%d", numActive(page.sessions))
 	p3.PaddingLeft = 2
-	p3.SetRect(30, 7, 61, 10)
 
-	return p, p2, p3
+	grid := ui.NewGrid()
+	grid.Set(
+		ui.NewRow(0.5, p),
+		ui.NewRow(0.5, ui.NewCol(0.5, p2), ui.NewCol(0.5, p3)),
+	)
+	return grid
 }
 
 type PieChartData struct {
@@ -155,42 +123,49 @@ func (sbo SortByOther) Less(i, j int) bool {
 	return sbo.data[i] > sbo.data[j]
 }
 
-func nameProportions(sessions []Session) (*widgets.PieChart, []ui.Drawable) {
-	nameTime := make(map[string]float64)
+func nameDurations(sessions []Session) map[string]time.Duration {
+	nameTime := make(map[string]time.Duration)
 	for _, session := range sessions {
 		if !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
+			nameTime[session.Name] += calcDuration(session)
 		}
 	}
+	return nameTime
+}
 
-	colors := []ui.Color{ui.ColorRed, ui.ColorGreen, ui.ColorYellow,
-		ui.ColorBlue, ui.ColorCyan, ui.ColorMagenta}
+func nameProportions(page pageData) ui.Drawable {
+	// A pie chart of a single name says nothing, so pages restricted to a
+	// name show its hours over the page's range instead
+	if page.name != "" {
+		return historyChart(page)
+	}
+	currentTime := nameDurations(page.sessions)
+	previousTime := nameDurations(page.previous)
 
+	// Names are taken longest first, so they keep their order and colours
+	// from one refresh to the next. Those beyond the last colour share it as
+	// Other.
 	labels := []string{}
 	data := []float64{}
-	i := 0
-	numColors := len(colors)
-	for name, time := range nameTime {
-		if time < 0 {
-			time *= -1
-		}
-		if i > 5 {
-			data[numColors-1] += time
+	numColors := len(activeTheme.names)
+	hasOther := len(currentTime) > numColors
+	for i, name := range sortedNames(currentTime) {
+		minutes := math.Abs(currentTime[name].Minutes())
+		if hasOther && i >= numColors-1 {
+			if i == numColors-1 {
+				data = append(data, 0)
+				labels = append(labels, "Other")
+			}
+			data[numColors-1] += minutes
 		} else {
-			data = append(data, time)
+			data = append(data, minutes)
 			labels = append(labels, name)
 		}
-		i++
-	}
-	if len(nameTime) > numColors {
-		labels[numColors-1] = "Other"
 	}
 
 	pcData := PieChartData{data: data, labels: labels}
-	sort.Sort(SortByOther(pcData))
+	sort.Stable(SortByOther(pcData))
+	colors := activeTheme.nameColors(pcData.labels)
 
 	pc := widgets.NewPieChart()
 	pc.Title = "Session names"
@@ -204,137 +179,121 @@ func nameProportions(sessions []Session) (*widgets.PieChart, []ui.Drawable) {
 	pc.LabelFormatter = func(i int, v float64) string {
 		return fmt.Sprintf("%.02f", v)
 	}
-	pc.SetRect(61, 4, 112, 29)
 
-	labelComponents := make([]ui.Drawable, len(pcData.labels))
+	legend := &chartLegend{Block: *ui.NewBlock()}
 	for i, name := range pcData.labels {
 		p := widgets.NewParagraph()
-		p.TextStyle = ui.NewStyle(ui.ColorGreen)
 		p.Border = false
-		if name == "" {
-			name = "none"
+		p.Text = displayName(name)
+		isOther := name == "Other" && hasOther
+		if page.hasPrevious && !isOther {
+			p.Text += " " + changeMarkup(currentTime[name], previousTime[name])
 		}
-		p.Text = name
 		p.TextStyle = ui.NewStyle(colors[i])
 		p.PaddingLeft = 1
-		p.SetRect(112, 4+(i*2), 140, 7+(i*2))
-		labelComponents[i] = p
+		if isOther {
+			legend.labels = append(legend.labels, p)
+		} else {
+			legend.labels = append(legend.labels, &nameLabel{Paragraph: p, name: name})
+		}
 	}
 
-	return pc, labelComponents
+	grid := ui.NewGrid()
+	grid.Set(ui.NewCol(0.6, pc), ui.NewCol(0.4, legend))
+	return grid
 }
 
-func sessionsList(sessions []Session) *widgets.List {
-	l := widgets.NewList()
-	l.Title = "Sessions"
-	rows := []string{}
-	for _, session := range sessions {
-		name := "none"
-		if session.Name != "" {
-			name = session.Name
-		}
-		duration := totalDuration(sessions)
-		rows = append(rows, fmt.Sprintf("[%d] %s - %s", session.ID, name,
-			formatDuration(duration, 3)))
-	}
-	l.Rows = rows
-	l.PaddingLeft = 2
-	l.PaddingRight = 2
-	l.PaddingTop = 1
-	l.PaddingBottom = 1
-	l.WrapText = true
-	// l.TextStyle = ui.NewStyle(ui.ColorYellow)
-	l.SetRect(0, 10, 61, 29)
-	return l
+// chartLegend draws the labels of a chart one below the other, as many as
+// fit in its area.
+type chartLegend struct {
+	ui.Block
+	labels []ui.Drawable
 }
 
-func lastWeek(sessions []Session) *widgets.BarChart {
-	bc := widgets.NewBarChart()
-	data := make([]float64, 7)
-	now := time.Now()
-	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0,
-		now.Location())
-	for _, session := range sessions {
-		if !session.Finish.IsZero() {
-			day := time.Date(session.Start.Year(),
-				session.Start.Month(),
-				session.Start.Day(), 0, 0, 0, 0,
-				session.Start.Location())
-			daysAgo := int(today.Sub(day).Hours() / 24.0)
-			sessionDuration := session.Finish.Sub(session.Start).Minutes()
-			data[6-daysAgo] += sessionDuration
+func (l *chartLegend) Draw(buf *ui.Buffer) {
+	for i, label := range l.labels {
+		y := l.Min.Y + i*2
+		if y+3 > l.Max.Y {
+			break
 		}
+		label.SetRect(l.Min.X, y, l.Max.X, y+3)
+		label.Draw(buf)
 	}
+}
 
-	for i, val := range data {
-		data[i] = roundFloat(val, 0)
-	}
-
-	labels := make([]string, 7)
-	for i := 0; i < 7; i++ {
-		weekday := now.Add(-time.Duration((6-i)*24) * time.Hour).Weekday().String()
-		labels[i] = weekday[:3]
-	}
-
-	bc.Data = data
-
-	bc.Labels = labels
-	bc.Title = "Last 7 days"
-	bc.BarWidth = 7
-	bc.PaddingLeft = 10
-	bc.BarColors = []ui.Color{ui.ColorGreen}
-	bc.LabelStyles = []ui.Style{ui.NewStyle(ui.ColorBlue)}
-	bc.NumStyles = []ui.Style{ui.NewStyle(ui.ColorYellow)}
-	bc.PaddingTop = 1
-	bc.PaddingLeft = 2
-	bc.PaddingRight = 2
-	bc.SetRect(0, 10, 61, 29)
+// Widest bars of the day charts, enough for the minutes of a full day
+const dayBarWidth = 7
 
-	return bc
+// fittedBarChart narrows its bars to fit its width, up to maxBarWidth
+// columns each, labelling only some of them if the bars get too narrow for
+// their labels. Bars that do not fit even a column wide are left out from the
+// start.
+type fittedBarChart struct {
+	*widgets.BarChart
+	maxBarWidth int
+	labels      []string
 }
 
-func shiftedSlice(slice []float64, amount int) []float64 {
-	shifted := make([]float64, len(slice))
-	for i, val := range slice {
-		idx := (i + amount) % len(slice)
-		shifted[idx] = val
-	}
-	return shifted
+func newFittedBarChart(labels []string, maxBarWidth int) *fittedBarChart {
+	return &fittedBarChart{BarChart: widgets.NewBarChart(), maxBarWidth: maxBarWidth, labels: labels}
 }
 
-func shiftData(data []float64, now time.Time) []float64 {
-	switch now.Weekday().String() {
-	case "Monday":
-		return shiftedSlice(data, 1)
-	case "Tuesday":
-		return shiftedSlice(data, 2)
-	case "Wednesday":
-		return shiftedSlice(data, 3)
-	case "Thursday":
-		return shiftedSlice(data, 4)
-	case "Friday":
-		return shiftedSlice(data, 5)
-	case "Saturday":
-		return shiftedSlice(data, 6)
-	}
-	return data
+func (bc *fittedBarChart) Draw(buf *ui.Buffer) {
+	data, labels := bc.Data, bc.labels
+	if fit := bc.Inner.Dx() / (1 + bc.BarGap); len(data) > fit && fit > 0 {
+		data, labels = data[len(data)-fit:], labels[len(labels)-fit:]
+	}
+	bc.BarWidth = bc.maxBarWidth
+	if n := len(data); n > 0 && bc.Inner.Dx()/n-bc.BarGap < bc.BarWidth {
+		bc.BarWidth = bc.Inner.Dx()/n - bc.BarGap
+	}
+	if bc.BarWidth < 1 {
+		bc.BarWidth = 1
+	}
+	// Labels too wide for their bars go under every few bars instead,
+	// counting back from the last
+	step := 1
+	for _, label := range labels {
+		if n := (len(label) + bc.BarWidth + bc.BarGap) / (bc.BarWidth + bc.BarGap); n > step {
+			step = n
+		}
+	}
+	bc.Labels = make([]string, len(labels))
+	for i, label := range labels {
+		if (len(labels)-1-i)%step == 0 {
+			bc.Labels[i] = label
+		}
+	}
+	// Numbers wider than the bars would run into each other
+	all, format := bc.Data, bc.NumFormatter
+	bc.Data = data
+	bc.NumFormatter = func(v float64) string {
+		if number := format(v); len(number) <= bc.BarWidth {
+			return number
+		}
+		return ""
+	}
+	bc.BarChart.Draw(buf)
+	bc.Data, bc.NumFormatter = all, format
 }
 
-func weekAverage(sessions []Session) *widgets.BarChart {
-	bc := widgets.NewBarChart()
-	data := make([]float64, 7)
-	now := time.Now()
+func lastWeek(page pageData) ui.Drawable {
+	now := CurrentTime()
 	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0,
 		now.Location())
-	for _, session := range sessions {
+	days := make([]time.Time, 7)
+	labels := make([]string, 7)
+	for i := range days {
+		days[i] = today.AddDate(0, 0, i-6)
+		labels[i] = days[i].Weekday().String()[:3]
+	}
+
+	data := make([]float64, 7)
+	for _, session := range page.sessions {
 		if !session.Finish.IsZero() {
-			day := time.Date(session.Start.Year(),
-				session.Start.Month(),
-				session.Start.Day(), 0, 0, 0, 0,
-				session.Start.Location())
-			daysAgo := int(today.Sub(day).Hours() / 24.0)
-			sessionDuration := session.Finish.Sub(session.Start).Minutes()
-			data[6-daysAgo] += sessionDuration
+			for i, duration := range durationPerDay(session, days) {
+				data[i] += duration.Minutes()
+			}
 		}
 	}
 
@@ -342,370 +301,483 @@ func weekAverage(sessions []Session) *widgets.BarChart {
 		data[i] = roundFloat(val, 0)
 	}
 
-	// Circular shift data to make sure today's day is the final element to match labels
-	data = shiftData(data, now)
-
+	bc := newFittedBarChart(labels, dayBarWidth)
 	bc.Data = data
-	bc.Labels = []string{"Mon", "Tue", "Wed", "Thu", "Fri", "Sat", "Sun"}
 	bc.Title = "Last 7 days"
-	bc.BarWidth = 7
 	bc.PaddingLeft = 10
-	bc.BarColors = []ui.Color{ui.ColorGreen}
-	bc.LabelStyles = []ui.Style{ui.NewStyle(ui.ColorBlue)}
-	bc.NumStyles = []ui.Style{ui.NewStyle(ui.ColorYellow)}
+	bc.BarColors = []ui.Color{activeTheme.bars[0]}
+	bc.LabelStyles = []ui.Style{ui.NewStyle(activeTheme.label)}
+	bc.NumStyles = []ui.Style{ui.NewStyle(activeTheme.numbers)}
 	bc.PaddingTop = 1
 	bc.PaddingLeft = 2
 	bc.PaddingRight = 2
-	bc.SetRect(0, 10, 61, 29)
-
 	return bc
 }
 
-func (a *All) buildComponents() {
-	p, p2, p3 := basicInfo(a.sessions)
-
-	bc := weekAverage(a.sessions)
-
-	pc, pcLabels := nameProportions(a.sessions)
-
-	components := []ui.Drawable{p, p2, p3, bc, pc}
-	components = append(components, pcLabels...)
-	a.components = components
-}
-
-func (t *Today) buildComponents() {
-	p, p2, p3 := basicInfo(t.sessions)
-
-	nameTime := make(map[string]float64)
-	for _, session := range t.sessions {
-		if session.Name != "" && !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
-		}
+func weekAverage(page pageData) ui.Drawable {
+	data := make([]float64, 7)
+	for i, duration := range weekdayAverages(page.sessions, page.start, page.end) {
+		data[i] = roundFloat(duration.Minutes(), 0)
 	}
 
-	l := sessionsList(t.sessions)
-
-	pc, pcLabels := nameProportions(t.sessions)
-
-	components := []ui.Drawable{p, p2, p3, pc, l}
-	components = append(components, pcLabels...)
-	t.components = components
-	t.list = l
+	bc := newFittedBarChart(weekdayLabels, dayBarWidth)
+	bc.Data = data
+	bc.Title = "Average per weekday"
+	bc.PaddingLeft = 10
+	bc.BarColors = []ui.Color{activeTheme.bars[0]}
+	bc.LabelStyles = []ui.Style{ui.NewStyle(activeTheme.label)}
+	bc.NumStyles = []ui.Style{ui.NewStyle(activeTheme.numbers)}
+	bc.PaddingTop = 1
+	bc.PaddingLeft = 2
+	bc.PaddingRight = 2
+	return bc
 }
 
-func (d *Day) buildComponents() {
-	p, p2, p3 := basicInfo(d.sessions)
-
-	nameTime := make(map[string]float64)
-	for _, session := range d.sessions {
-		if session.Name != "" && !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
-		}
+// typeKey applies a key pressed in the prompt to the text typed so far,
+// reporting whether the key is one that edits text. Backspace removes the
+// last character, and keys that type a character, which termui names by the
+// character itself, add it.
+func typeKey(input string, key string) (string, bool) {
+	switch key {
+	case "<Backspace>", "<C-<Backspace>>":
+		_, size := utf8.DecodeLastRuneInString(input)
+		return input[:len(input)-size], true
+	case "<Space>":
+		return input + " ", true
 	}
-
-	l := sessionsList(d.sessions)
-
-	pc, pcLabels := nameProportions(d.sessions)
-
-	components := []ui.Drawable{p, p2, p3, pc, l}
-	components = append(components, pcLabels...)
-	d.components = components
-	d.list = l
-}
-
-func (w *Week) buildComponents() {
-	p, p2, p3 := basicInfo(w.sessions)
-
-	bc := lastWeek(w.sessions)
-
-	nameTime := make(map[string]float64)
-	for _, session := range w.sessions {
-		if session.Name != "" && !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
-		}
+	if utf8.RuneCountInString(key) == 1 {
+		return input + key, true
 	}
-
-	pc, pcLabels := nameProportions(w.sessions)
-
-	components := []ui.Drawable{p, p2, p3, bc, pc}
-	components = append(components, pcLabels...)
-	w.components = components
+	return input, false
 }
 
-func (m *Month) buildComponents() {
-	p, p2, p3 := basicInfo(m.sessions)
+// How often the open tab of the stats page is fetched again
+const refreshInterval = time.Second
 
-	bc := weekAverage(m.sessions)
+// Number of lines below the pages for help text and prompts
+const helpHeight = 6
 
-	nameTime := make(map[string]float64)
-	for _, session := range m.sessions {
-		if session.Name != "" && !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
-		}
-	}
-
-	pc, pcLabels := nameProportions(m.sessions)
-
-	components := []ui.Drawable{p, p2, p3, bc, pc}
-	components = append(components, pcLabels...)
-	m.components = components
+// pageArea returns the part of a terminal of the given size that pages are
+// drawn in, between the tabs and the help text.
+func pageArea(width int, height int) image.Rectangle {
+	return image.Rect(0, 3, width, height-helpHeight)
 }
 
-func (y *Year) buildComponents() {
-	p, p2, p3 := basicInfo(y.sessions)
-
-	bc := weekAverage(y.sessions)
-
-	nameTime := make(map[string]float64)
-	for _, session := range y.sessions {
-		if session.Name != "" && !session.Finish.IsZero() {
-			if _, ok := nameTime[session.Name]; !ok {
-				nameTime[session.Name] = 0.0
-			}
-			nameTime[session.Name] += session.Finish.Sub(session.Start).Minutes()
-		}
-	}
-
-	pc, pcLabels := nameProportions(y.sessions)
+// Directions passed to Page.scroll by the scrolling actions of the keymap
+var scrollDirections = map[string]string{
+	"down":     "down",
+	"up":       "up",
+	"pageDown": "page down",
+	"pageUp":   "page up",
+}
 
-	components := []ui.Drawable{p, p2, p3, bc, pc}
-	components = append(components, pcLabels...)
-	y.components = components
+// StatsOptions adds a page for a custom date range to the stats page when
+// Range is set, and restricts every page to sessions with Name when it is
+// set. Width and Tab are only used when printing the stats.
+type StatsOptions struct {
+	Range DateRange
+	Name  string
+	Width int
+	Tab   string
 }
 
-func (a All) scroll(direction string) {
-	if a.list != nil {
-		if direction == "up" {
-			a.list.ScrollUp()
-			ui.Render(a.list)
-		} else if direction == "down" {
-			a.list.ScrollDown()
-			ui.Render(a.list)
-		}
+func DisplayStats(db *sql.DB, options StatsOptions) error {
+	config, err := LoadConfig()
+	if err != nil {
+		return err
 	}
-}
+	keys, err := loadKeymap(config)
+	if err != nil {
+		return err
+	}
+	actions := keys.actions()
 
-func (t Today) scroll(direction string) {
-	if t.list != nil {
-		if direction == "up" {
-			t.list.ScrollPageUp()
-			ui.Render(t.list)
-		} else if direction == "down" {
-			t.list.ScrollPageDown()
-			ui.Render(t.list)
+	pages, err := buildPages(db, defaultPages, options.Name)
+	if err != nil {
+		return err
+	}
+	// Name every page is restricted to, if any
+	name := options.Name
+	tabNames := pageTitles(pages)
+	// Index of the custom range page, once one has been added
+	customIndex := -1
+	addCustomPage := func(dateRange DateRange) error {
+		page, err := buildPage(db, customPage(dateRange), name)
+		if err != nil {
+			return err
 		}
+		page.resize(pages[0].area)
+		if customIndex == -1 {
+			customIndex = len(pages)
+			pages = append(pages, nil)
+			tabNames = append(tabNames, "")
+		}
+		pages[customIndex] = page
+		tabNames[customIndex] = dateRange.Label
+		return nil
 	}
-}
-
-func (d Day) scroll(direction string) {
-	if d.list != nil {
-		if direction == "up" {
-			d.list.ScrollPageUp()
-			ui.Render(d.list)
-		} else if direction == "down" {
-			d.list.ScrollPageDown()
-			ui.Render(d.list)
+	if !options.Range.Start.IsZero() || !options.Range.End.IsZero() {
+		if err := addCustomPage(options.Range); err != nil {
+			return err
 		}
 	}
-}
 
-func (w Week) scroll(direction string) {
-	if w.list != nil {
-		if direction == "up" {
-			w.list.ScrollPageUp()
-			ui.Render(w.list)
-		} else if direction == "down" {
-			w.list.ScrollPageDown()
-			ui.Render(w.list)
-		}
+	if err := ui.Init(); err != nil {
+		log.Fatalf("failed to initialize termui: %v", err)
 	}
-}
+	defer ui.Close()
 
-func (m Month) scroll(direction string) {
-	if m.list != nil {
-		if direction == "up" {
-			m.list.ScrollPageUp()
-			ui.Render(m.list)
-		} else if direction == "down" {
-			m.list.ScrollPageDown()
-			ui.Render(m.list)
-		}
+	tabpane := widgets.NewTabPane(tabNames...)
+	tabpane.Border = false
+	if customIndex != -1 {
+		tabpane.ActiveTabIndex = customIndex
 	}
-}
 
-func (y Year) scroll(direction string) {
-	if y.list != nil {
-		if direction == "up" {
-			y.list.ScrollPageUp()
-			ui.Render(y.list)
-		} else if direction == "down" {
-			y.list.ScrollPageDown()
-			ui.Render(y.list)
+	signOff := widgets.NewParagraph()
+	signOff.Border = false
+	signOff.Text = keys.hints()
+
+	// Detail view of the sessions with the name selected in the pie chart
+	var detail *Page
+	detailTitle := widgets.NewParagraph()
+	detailTitle.Border = false
+	detailHelp := widgets.NewParagraph()
+	detailHelp.Border = false
+	detailHelp.Text = fmt.Sprintf("Use %s and %s to scroll.\nPress %s to return.",
+		keys.label("down"), keys.label("up"), keys.label("back"))
+
+	// Overlay listing every key, shown over the current view
+	help := widgets.NewParagraph()
+	help.Title = "Keys"
+	help.Text = keys.help()
+	help.PaddingLeft = 1
+	showHelp := false
+
+	// Prompt for text input, such as a custom date range or a new session
+	// name. submit is called with the input on enter, and is nil when the
+	// prompt is closed.
+	prompt := widgets.NewParagraph()
+	var submit func(input string) error
+	input := ""
+	// Error from the last attempt to fetch sessions, such as after losing
+	// the connection to the database, shown in the prompt until a fetch
+	// succeeds
+	var fetchErr error
+
+	// resize fits the tabs, help text and every page to the terminal
+	resize := func(width int, height int) {
+		tabpane.SetRect(0, 1, width, 3)
+		detailTitle.SetRect(0, 1, width, 3)
+		signOff.SetRect(0, height-helpHeight, width, height)
+		detailHelp.SetRect(0, height-helpHeight, width, height)
+		prompt.SetRect(0, height-helpHeight, width, height-helpHeight+3)
+		helpWidth := 64
+		if width < helpWidth {
+			helpWidth = width
+		}
+		helpLines := strings.Count(help.Text, "\n") + 3
+		help.SetRect((width-helpWidth)/2, 1, (width+helpWidth)/2, 1+helpLines)
+		for _, page := range pages {
+			page.resize(pageArea(width, height))
+		}
+		if detail != nil {
+			detail.resize(pageArea(width, height))
 		}
 	}
-}
+	resize(ui.TerminalDimensions())
 
-func (a All) render() {
-	for _, component := range a.components {
-		ui.Render(component)
+	// Render everything at once so that refreshing the page does not flicker
+	renderDashboard := func() {
+		ui.Clear()
+		ui.Render(tabpane, signOff, pages[tabpane.ActiveTabIndex].grid)
+		if fetchErr != nil {
+			prompt.Title = "Error"
+			prompt.Text = "Could not fetch sessions: " + fetchErr.Error()
+			ui.Render(prompt)
+		}
 	}
-}
 
-func (t Today) render() {
-	for _, component := range t.components {
-		ui.Render(component)
+	renderDetail := func() {
+		detailTitle.Text = fmt.Sprintf("Sessions named %s - %s", detail.definition.title,
+			tabNames[tabpane.ActiveTabIndex])
+		ui.Clear()
+		ui.Render(detailTitle, detailHelp)
+		detail.render()
 	}
-}
 
-func (d Day) render() {
-	for _, component := range d.components {
-		ui.Render(component)
+	renderInput := func(message string) {
+		prompt.Text = input + message
+		ui.Clear()
+		ui.Render(tabpane, prompt, pages[tabpane.ActiveTabIndex].grid)
 	}
-}
 
-func (w Week) render() {
-	for _, component := range w.components {
-		ui.Render(component)
+	renderHelp := func() {
+		if detail != nil {
+			renderDetail()
+		} else {
+			renderDashboard()
+		}
+		ui.Render(help)
 	}
-}
 
-func (m Month) render() {
-	for _, component := range m.components {
-		ui.Render(component)
+	startInput := func(title string, initial string, onSubmit func(input string) error) {
+		prompt.Title = title
+		input = initial
+		submit = onSubmit
+		renderInput("")
 	}
-}
 
-func (y Year) render() {
-	for _, component := range y.components {
-		ui.Render(component)
+	// refresh fetches every page again after a session is changed
+	refresh := func() error {
+		for _, page := range pages {
+			if err := page.refresh(db); err != nil {
+				return err
+			}
+		}
+		return nil
 	}
-}
-
-type Page interface {
-	fetchSessions(db *sql.DB)
-	buildComponents()
-	scroll(direction string)
-	render()
-}
 
-type All struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-type Today struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-type Day struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-type Week struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-type Month struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-type Year struct {
-	sessions   []Session
-	components []ui.Drawable
-	list       *widgets.List
-}
-
-func buildPages(db *sql.DB) []Page {
-	all := All{}
-	today := Today{}
-	day := Day{}
-	week := Week{}
-	month := Month{}
-	year := Year{}
-	pages := []Page{&all, &today, &day, &week, &month, &year}
+	// editSelected prompts for a change to the session selected in the
+	// current page's sessions table
+	editSelected := func(action string) {
+		table := pages[tabpane.ActiveTabIndex].table
+		if table == nil {
+			return
+		}
+		session, ok := table.selectedSession()
+		if !ok {
+			return
+		}
 
-	for _, page := range pages {
-		page.fetchSessions(db)
-		page.buildComponents()
+		// Changes are saved through the same functions as the edit, delete
+		// and finish commands, so they are audited and can be undone
+		startEdit := func(title string, initial string, change func(input string) error) {
+			startInput(title, initial, func(input string) error {
+				if err := change(input); err != nil {
+					return err
+				}
+				fetchErr = refresh()
+				return nil
+			})
+		}
+		editTime := func(value string) (*time.Time, error) {
+			t, err := ParseDateTime(value)
+			return &t, err
+		}
+		switch action {
+		case "rename":
+			startEdit(fmt.Sprintf("New name for session %d", session.ID), session.Name, func(input string) error {
+				_, err := editSession(db, session.ID, SessionEdit{Name: &input}, "stats")
+				return err
+			})
+		case "editStart":
+			startEdit(fmt.Sprintf("New start for session %d (YYYY-MM-DD HH:MM)", session.ID),
+				session.Start.Format("2006-01-02 15:04"), func(input string) error {
+					start, err := editTime(input)
+					if err != nil {
+						return err
+					}
+					_, err = editSession(db, session.ID, SessionEdit{Start: start}, "stats")
+					return err
+				})
+		case "editFinish":
+			initial := CurrentTime()
+			if !session.Finish.IsZero() {
+				initial = session.Finish
+			}
+			startEdit(fmt.Sprintf("New finish for session %d (YYYY-MM-DD HH:MM)", session.ID),
+				initial.Format("2006-01-02 15:04"), func(input string) error {
+					finish, err := editTime(input)
+					if err != nil {
+						return err
+					}
+					_, err = editSession(db, session.ID, SessionEdit{Finish: finish}, "stats")
+					return err
+				})
+		case "delete":
+			startEdit(fmt.Sprintf("Delete session %d %s? Type y to confirm", session.ID, displayName(session.Name)),
+				"", func(input string) error {
+					if input != "y" {
+						return nil
+					}
+					_, err := deleteSession(db, session.ID, "stats")
+					return err
+				})
+		case "stop":
+			if !session.Finish.IsZero() {
+				return
+			}
+			startEdit(fmt.Sprintf("Stop session %d %s? Type y to confirm", session.ID, displayName(session.Name)),
+				"y", func(input string) error {
+					if input != "y" {
+						return nil
+					}
+					return stopSession(db, session.ID, "stats")
+				})
+		}
 	}
 
-	return pages
-}
-
-func DisplayStats(db *sql.DB) error {
-	pages := buildPages(db)
+	renderDashboard()
 
-	if err := ui.Init(); err != nil {
-		log.Fatalf("failed to initialize termui: %v", err)
-	}
-	defer ui.Close()
-
-	tabpane := widgets.NewTabPane("All", "Today", "24hrs", "Week", "Month", "Year")
-	tabpane.SetRect(0, 1, 50, 3)
-	tabpane.Border = false
+	uiEvents := ui.PollEvents()
+	ticker := time.NewTicker(refreshInterval)
+	defer ticker.Stop()
 
-	signOff := widgets.NewParagraph()
-	signOff.Border = false
-	signOff.Text = "Use arrow keys to switch tabs.\nPress esc to quit."
-	signOff.SetRect(0, 29, 58, 34)
-	ui.Render(signOff)
+	for {
+		var e ui.Event
+		select {
+		case e = <-uiEvents:
+		case <-ticker.C:
+			// Update running sessions and pick up changes made from other
+			// terminals, unless the user is in the middle of something
+			if detail == nil && submit == nil && !showHelp {
+				fetchErr = pages[tabpane.ActiveTabIndex].refresh(db)
+				renderDashboard()
+			}
+			continue
+		}
 
-	renderTab := func() {
-		page := pages[tabpane.ActiveTabIndex]
-		page.render()
-	}
+		if e.ID == "<Resize>" {
+			size := e.Payload.(ui.Resize)
+			resize(size.Width, size.Height)
+			switch {
+			case showHelp:
+				renderHelp()
+			case detail != nil:
+				renderDetail()
+			case submit != nil:
+				renderInput("")
+			default:
+				renderDashboard()
+			}
+			continue
+		}
 
-	ui.Render(tabpane, signOff)
-	renderTab()
+		// Text typed into the prompt is not looked up in the keymap
+		if submit != nil {
+			switch e.ID {
+			case "<C-c>":
+				return nil
+			case "<Escape>":
+				submit = nil
+				renderDashboard()
+			case "<Enter>":
+				err := submit(input)
+				if err != nil {
+					renderInput(" - " + err.Error())
+					continue
+				}
+				submit = nil
+				renderDashboard()
+			default:
+				if edited, ok := typeKey(input, e.ID); ok {
+					input = edited
+					renderInput("")
+				}
+			}
+			continue
+		}
 
-	uiEvents := ui.PollEvents()
+		action := actions[e.ID]
+		if showHelp {
+			switch action {
+			case "quit":
+				return nil
+			case "help", "back":
+				showHelp = false
+				if detail != nil {
+					renderDetail()
+				} else {
+					renderDashboard()
+				}
+			}
+			continue
+		}
+		if action == "help" {
+			showHelp = true
+			renderHelp()
+			continue
+		}
+		if detail != nil {
+			switch action {
+			case "quit":
+				return nil
+			case "back":
+				detail = nil
+				renderDashboard()
+			case "down", "up", "pageDown", "pageUp":
+				detail.scroll(scrollDirections[action])
+			}
+			continue
+		}
 
-	for {
-		e := <-uiEvents
-		switch e.ID {
-		case "q", "<C-c>", "<Escape>":
+		switch action {
+		case "quit", "back":
 			return nil
-		case "<Left>", "p", "l":
+		case "dateRange":
+			startInput("Date range (e.g. 90d or 2026-09-01 2026-09-30)", "", func(input string) error {
+				dateRange, err := parseDateRange(input)
+				if err != nil {
+					return err
+				}
+				if err := addCustomPage(dateRange); err != nil {
+					return err
+				}
+				tabpane.TabNames = tabNames
+				tabpane.ActiveTabIndex = customIndex
+				return nil
+			})
+		case "left":
 			tabpane.FocusLeft()
-			ui.Clear()
-			ui.Render(tabpane, signOff)
-			renderTab()
-		case "<Right>", "n", "r":
+			renderDashboard()
+		case "right":
 			tabpane.FocusRight()
-			ui.Clear()
-			ui.Render(tabpane, signOff)
-			renderTab()
-		case "j", "<Down>":
-			// If on Today or Day page (containing list)
-			if tabpane.ActiveTabIndex == 1 || tabpane.ActiveTabIndex == 2 {
-				pages[tabpane.ActiveTabIndex].scroll("down")
+			renderDashboard()
+		case "tab1", "tab2", "tab3", "tab4", "tab5", "tab6", "tab7", "tab8", "tab9":
+			if tab := int(action[3] - '1'); tab < len(pages) {
+				tabpane.ActiveTabIndex = tab
+				renderDashboard()
+			}
+		case "down", "up", "pageDown", "pageUp":
+			if fetchErr = pages[tabpane.ActiveTabIndex].scroll(scrollDirections[action]); fetchErr != nil {
+				renderDashboard()
+			}
+		case "selectName":
+			pages[tabpane.ActiveTabIndex].selectNextName()
+		case "open":
+			page := pages[tabpane.ActiveTabIndex]
+			if name, ok := page.selectedName(); ok {
+				detail = page.nameDetail(name)
+				renderDetail()
+			}
+		case "filterName":
+			// Start from the name selected in the pie chart, if there is one
+			initial := name
+			if selected, ok := pages[tabpane.ActiveTabIndex].selectedName(); ok {
+				initial = selected
+			}
+			startInput("Show only sessions named (empty for every name)", initial, func(input string) error {
+				name = strings.TrimSpace(input)
+				for _, page := range pages {
+					if err := page.filterName(db, name); err != nil {
+						return err
+					}
+				}
+				return nil
+			})
+		case "sort", "reverseSort":
+			if fetchErr = pages[tabpane.ActiveTabIndex].sort(action == "reverseSort"); fetchErr != nil {
+				renderDashboard()
 			}
-		case "k", "<Up>":
-			// If on Today or Day page (containing list)
-			if tabpane.ActiveTabIndex == 1 || tabpane.ActiveTabIndex == 2 {
-				pages[tabpane.ActiveTabIndex].scroll("up")
+		case "search":
+			if browser := pages[tabpane.ActiveTabIndex].browser; browser != nil {
+				startInput("Search sessions by name or date (YYYY-MM-DD)", browser.filter.text,
+					func(input string) error {
+						return browser.search(parseSessionFilter(input))
+					})
 			}
+		case "rename", "editStart", "editFinish", "delete", "stop":
+			editSelected(action)
 		}
 	}
 }
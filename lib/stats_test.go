@@ -0,0 +1,31 @@
+package clockin
+
+import "testing"
+
+func TestTypeKey(t *testing.T) {
+	tests := []struct {
+		input string
+		key   string
+		want  string
+		ok    bool
+	}{
+		{input: "wri", key: "t", want: "writ", ok: true},
+		{input: "", key: "é", want: "é", ok: true},
+		{input: "caf", key: "é", want: "café", ok: true},
+		{input: "日本", key: "語", want: "日本語", ok: true},
+		{input: "a", key: "<Space>", want: "a ", ok: true},
+		{input: "a", key: "<", want: "a<", ok: true},
+		{input: "café", key: "<Backspace>", want: "caf", ok: true},
+		{input: "日本語", key: "<C-<Backspace>>", want: "日本", ok: true},
+		{input: "", key: "<Backspace>", want: "", ok: true},
+		{input: "a", key: "<Left>", want: "a", ok: false},
+		{input: "a", key: "<C-d>", want: "a", ok: false},
+	}
+
+	for _, test := range tests {
+		got, ok := typeKey(test.input, test.key)
+		if got != test.want || ok != test.ok {
+			t.Errorf("typeKey(%q, %q) = %q, %t, want %q, %t", test.input, test.key, got, ok, test.want, test.ok)
+		}
+	}
+}
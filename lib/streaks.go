@@ -0,0 +1,130 @@
+package clockin
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/TwiN/go-color"
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+)
+
+// streakStats measures how consistently time is recorded. A day counts
+// towards a streak once its recorded time reaches the minimum.
+type streakStats struct {
+	current        int
+	longest        int
+	daysPerWeek    float64
+	averageSession time.Duration
+	longestSession Session
+}
+
+func buildStreakStats(sessions []Session, now time.Time, minimum time.Duration) streakStats {
+	stats := streakStats{}
+	first := time.Time{}
+	var total time.Duration
+	completed := 0
+	for _, session := range sessions {
+		if session.Finish.IsZero() {
+			continue
+		}
+		if first.IsZero() || session.Start.Before(first) {
+			first = session.Start
+		}
+		duration := calcDuration(session)
+		total += duration
+		completed++
+		if duration > calcDuration(stats.longestSession) {
+			stats.longestSession = session
+		}
+	}
+	if completed == 0 {
+		return stats
+	}
+	stats.averageSession = total / time.Duration(completed)
+
+	days := daysBetween(startOfDay(first), startOfDay(now))
+	totals := dailyTotals(sessions, days)
+	run := 0
+	worked := 0
+	for _, total := range totals {
+		if total > 0 {
+			worked++
+		}
+		if total > 0 && total >= minimum {
+			run++
+			if run > stats.longest {
+				stats.longest = run
+			}
+		} else {
+			run = 0
+		}
+	}
+
+	// Today still counts towards the current streak if its minimum has not
+	// been reached yet, so it only breaks once the day is over
+	stats.current = run
+	if run == 0 && len(totals) > 1 {
+		for i := len(totals) - 2; i >= 0 && totals[i] > 0 && totals[i] >= minimum; i-- {
+			stats.current++
+		}
+	}
+
+	weeks := float64(len(days)) / 7
+	if weeks < 1 {
+		weeks = 1
+	}
+	stats.daysPerWeek = roundFloat(float64(worked)/weeks, 1)
+	return stats
+}
+
+func pluralDays(n int) string {
+	if n == 1 {
+		return "1 day"
+	}
+	return fmt.Sprintf("%d days", n)
+}
+
+// streakMinimum returns the configured minimum time for a day to count
+// towards a streak, falling back to the default if the config cannot be read.
+func streakMinimum() time.Duration {
+	config, err := LoadConfig()
+	if err != nil {
+		config = defaultConfig()
+	}
+	return time.Duration(config.StreakMinimum) * time.Minute
+}
+
+func streaksPanel(page pageData) ui.Drawable {
+	stats := buildStreakStats(page.sessions, CurrentTime(), streakMinimum())
+	p := widgets.NewParagraph()
+	p.Title = "Streaks"
+	p.Text = fmt.Sprintf("Current streak  [%s](fg:text)\nLongest streak  %s\nDays per week   %.1f\n"+
+		"Average session %s\nLongest session %s",
+		pluralDays(stats.current), pluralDays(stats.longest), stats.daysPerWeek,
+		formatHours(stats.averageSession), formatHours(calcDuration(stats.longestSession)))
+	p.PaddingLeft = 1
+	return p
+}
+
+// printStreaks shows the current and longest streaks of days with recorded
+// time, and how consistently sessions are recorded.
+func printStreaks(db *sql.DB) error {
+	sessions, err := querySessions(db, "deleted_at IS NULL AND finish IS NOT NULL")
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	stats := buildStreakStats(sessions, CurrentTime(), streakMinimum())
+	fmt.Printf("\nCurrent streak: %s (longest %s)\n",
+		color.Ize(color.Green, pluralDays(stats.current)), pluralDays(stats.longest))
+	fmt.Printf("Days worked per week: %.1f\n", stats.daysPerWeek)
+	fmt.Printf("Average session: %s\n", formatHours(stats.averageSession))
+	fmt.Printf("Longest session: %s (%s on %s)\n", formatHours(calcDuration(stats.longestSession)),
+		displayName(stats.longestSession.Name), stats.longestSession.Start.Format("2 Jan 2006"))
+	return nil
+}
@@ -0,0 +1,87 @@
+package clockin
+
+import (
+	"testing"
+	"time"
+)
+
+func TestBuildStreakStats(t *testing.T) {
+	now := dateAt(2026, 10, 18, 12)
+	// worked returns a session starting at 9am the given number of days
+	// before now
+	worked := func(daysAgo int, length time.Duration) Session {
+		start := dateAt(2026, 10, 18-daysAgo, 9)
+		return Session{Start: start, Finish: start.Add(length)}
+	}
+
+	tests := []struct {
+		name        string
+		sessions    []Session
+		current     int
+		longest     int
+		daysPerWeek float64
+	}{
+		{name: "no sessions"},
+		{
+			name:     "running sessions only",
+			sessions: []Session{{Start: dateAt(2026, 10, 18, 9)}},
+		},
+		{
+			name:        "today not yet worked",
+			sessions:    []Session{worked(3, time.Hour), worked(2, time.Hour), worked(1, time.Hour)},
+			current:     3,
+			longest:     3,
+			daysPerWeek: 3,
+		},
+		{
+			name:        "including today",
+			sessions:    []Session{worked(2, time.Hour), worked(1, time.Hour), worked(0, time.Hour)},
+			current:     3,
+			longest:     3,
+			daysPerWeek: 3,
+		},
+		{
+			name: "broken by a day off",
+			sessions: []Session{worked(6, time.Hour), worked(5, time.Hour), worked(4, time.Hour),
+				worked(3, time.Hour), worked(1, time.Hour), worked(0, time.Hour)},
+			current:     2,
+			longest:     4,
+			daysPerWeek: 6,
+		},
+		{
+			name:        "broken by a short day",
+			sessions:    []Session{worked(2, time.Hour), worked(1, 10*time.Minute), worked(0, time.Hour)},
+			current:     1,
+			longest:     1,
+			daysPerWeek: 3,
+		},
+		{
+			name:        "over several weeks",
+			sessions:    []Session{worked(13, time.Hour), worked(0, time.Hour)},
+			current:     1,
+			longest:     1,
+			daysPerWeek: 1,
+		},
+	}
+
+	for _, test := range tests {
+		stats := buildStreakStats(test.sessions, now, 30*time.Minute)
+		if stats.current != test.current || stats.longest != test.longest || stats.daysPerWeek != test.daysPerWeek {
+			t.Errorf("%s: got current %d, longest %d, %.1f days a week; want %d, %d, %.1f", test.name,
+				stats.current, stats.longest, stats.daysPerWeek, test.current, test.longest, test.daysPerWeek)
+		}
+	}
+}
+
+func TestBuildStreakStatsSessions(t *testing.T) {
+	start := dateAt(2026, 10, 16, 9)
+	short := Session{ID: 1, Start: start, Finish: start.Add(time.Hour)}
+	long := Session{ID: 2, Start: start.AddDate(0, 0, 1), Finish: start.AddDate(0, 0, 1).Add(3 * time.Hour)}
+	stats := buildStreakStats([]Session{short, long}, dateAt(2026, 10, 18, 12), 30*time.Minute)
+	if stats.averageSession != 2*time.Hour {
+		t.Errorf("average session is %s, want 2h", stats.averageSession)
+	}
+	if stats.longestSession.ID != long.ID {
+		t.Errorf("longest session is %d, want %d", stats.longestSession.ID, long.ID)
+	}
+}
@@ -0,0 +1,89 @@
+package clockin
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"time"
+
+	"github.com/mattn/go-runewidth"
+)
+
+// textTable renders rows of text as aligned columns. A nil row is drawn as a
+// separator line, e.g. above a totals row.
+type textTable struct {
+	headers    []string
+	rows       [][]string
+	alignRight []bool
+}
+
+func (t *textTable) addRow(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+func (t *textTable) addSeparator() {
+	t.rows = append(t.rows, nil)
+}
+
+func (t textTable) columnWidths() []int {
+	widths := make([]int, len(t.headers))
+	for i, header := range t.headers {
+		widths[i] = runewidth.StringWidth(header)
+	}
+	for _, row := range t.rows {
+		for i, cell := range row {
+			if w := runewidth.StringWidth(cell); i < len(widths) && w > widths[i] {
+				widths[i] = w
+			}
+		}
+	}
+	return widths
+}
+
+func (t textTable) formatRow(row []string, widths []int) string {
+	cells := make([]string, len(widths))
+	for i, width := range widths {
+		cell := ""
+		if i < len(row) {
+			cell = row[i]
+		}
+		if i < len(t.alignRight) && t.alignRight[i] {
+			cells[i] = runewidth.FillLeft(cell, width)
+		} else {
+			cells[i] = runewidth.FillRight(cell, width)
+		}
+	}
+	return strings.TrimRight(strings.Join(cells, "   "), " ")
+}
+
+func (t textTable) render(w io.Writer) {
+	widths := t.columnWidths()
+	lineWidth := 3 * (len(widths) - 1)
+	for _, width := range widths {
+		lineWidth += width
+	}
+
+	fmt.Fprintln(w, t.formatRow(t.headers, widths))
+	fmt.Fprintln(w, strings.Repeat("-", lineWidth))
+	for _, row := range t.rows {
+		if row == nil {
+			fmt.Fprintln(w, strings.Repeat("-", lineWidth))
+		} else {
+			fmt.Fprintln(w, t.formatRow(row, widths))
+		}
+	}
+}
+
+// formatHours formats a duration compactly in hours and minutes for tables,
+// e.g. "12h 05m".
+func formatHours(duration time.Duration) string {
+	minutes := int(duration.Round(time.Minute).Minutes())
+	return fmt.Sprintf("%dh %02dm", minutes/60, minutes%60)
+}
+
+func formatPercentage(part time.Duration, total time.Duration) string {
+	if total == 0 {
+		return "0.0%"
+	}
+	return fmt.Sprintf("%.1f%%", 100*float64(part)/float64(total))
+}
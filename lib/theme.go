@@ -0,0 +1,259 @@
+package clockin
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/TwiN/go-color"
+	ui "github.com/gizak/termui/v3"
+)
+
+// theme holds the colours of the stats page and of the charts printed by the
+// heatmap and trend commands. Colours are 256-colour codes, with ColorClear
+// for the terminal's default.
+type theme struct {
+	// Figures such as the total duration, and running sessions
+	text   ui.Color
+	active ui.Color
+	// Chart labels and table headers
+	label ui.Color
+	// Bars of bar charts, alternating between them, and the numbers on them
+	bars    []ui.Color
+	numbers ui.Color
+	// Lines of the trend chart, the daily total and then the average
+	lines []ui.Color
+	axes  ui.Color
+	// Changes from the previous period, more time and then less
+	increase ui.Color
+	decrease ui.Color
+	// Names in the pie chart, in order of time recorded
+	names []ui.Color
+	// Heatmap levels from no time to the busiest days, each drawn as a block
+	// in a colour
+	heatmap       []ui.Color
+	heatmapBlocks []rune
+	border        ui.Color
+	tab           ui.Style
+	selected      ui.Style
+	// Colours chosen for names in the config
+	pinned map[string]ui.Color
+}
+
+var themes = map[string]theme{
+	"default": {
+		text:          ui.ColorGreen,
+		active:        ui.ColorYellow,
+		label:         ui.ColorBlue,
+		bars:          []ui.Color{ui.ColorGreen, ui.ColorCyan},
+		numbers:       ui.ColorYellow,
+		lines:         []ui.Color{ui.ColorGreen, ui.ColorYellow},
+		axes:          ui.ColorWhite,
+		increase:      ui.ColorGreen,
+		decrease:      ui.ColorRed,
+		names:         []ui.Color{ui.ColorRed, ui.ColorGreen, ui.ColorYellow, ui.ColorBlue, ui.ColorCyan, ui.ColorMagenta},
+		heatmap:       []ui.Color{238, 22, 28, 34, 40},
+		heatmapBlocks: []rune("■■■■■"),
+		border:        ui.ColorWhite,
+		tab:           ui.NewStyle(ui.ColorRed),
+		selected:      ui.NewStyle(ui.ColorBlack, ui.ColorGreen),
+	},
+	// Bright colours on black for dim displays and low vision
+	"high-contrast": {
+		text:          231,
+		active:        226,
+		label:         51,
+		bars:          []ui.Color{46, 51},
+		numbers:       16,
+		lines:         []ui.Color{46, 226},
+		axes:          231,
+		increase:      46,
+		decrease:      196,
+		names:         []ui.Color{196, 46, 226, 51, 201, 231},
+		heatmap:       []ui.Color{236, 28, 40, 82, 231},
+		heatmapBlocks: []rune("■■■■■"),
+		border:        231,
+		tab:           ui.NewStyle(ui.ColorBlack, ui.Color(226), ui.ModifierBold),
+		selected:      ui.NewStyle(ui.ColorBlack, ui.Color(226)),
+	},
+	// No colours, telling heatmap levels apart by shading and selections by
+	// reversing them. Bars are drawn in white as they are made of background
+	// colour.
+	"monochrome": {
+		text:          ui.ColorClear,
+		active:        ui.ColorClear,
+		label:         ui.ColorClear,
+		bars:          []ui.Color{ui.ColorWhite},
+		numbers:       ui.ColorBlack,
+		lines:         []ui.Color{ui.ColorClear},
+		axes:          ui.ColorClear,
+		increase:      ui.ColorClear,
+		decrease:      ui.ColorClear,
+		names:         []ui.Color{ui.ColorClear, ui.ColorClear, ui.ColorClear, ui.ColorClear, ui.ColorClear, ui.ColorClear},
+		heatmap:       []ui.Color{ui.ColorClear, ui.ColorClear, ui.ColorClear, ui.ColorClear, ui.ColorClear},
+		heatmapBlocks: []rune("·░▒▓█"),
+		border:        ui.ColorClear,
+		tab:           ui.NewStyle(ui.ColorClear, ui.ColorClear, ui.ModifierReverse),
+		selected:      ui.NewStyle(ui.ColorClear, ui.ColorClear, ui.ModifierReverse),
+	},
+	// The Okabe-Ito palette, which stays distinct with the common forms of
+	// colour blindness, with blue and orange in place of green and red
+	"colour-blind": {
+		text:          117,
+		active:        214,
+		label:         32,
+		bars:          []ui.Color{117, 32},
+		numbers:       214,
+		lines:         []ui.Color{117, 214},
+		axes:          ui.ColorWhite,
+		increase:      32,
+		decrease:      208,
+		names:         []ui.Color{214, 117, 36, 227, 32, 175},
+		heatmap:       []ui.Color{238, 24, 31, 38, 45},
+		heatmapBlocks: []rune("■■■■■"),
+		border:        ui.ColorWhite,
+		tab:           ui.NewStyle(ui.Color(214)),
+		selected:      ui.NewStyle(ui.ColorBlack, ui.Color(117)),
+	},
+}
+
+// activeTheme is the theme chosen in the config, set by SetupColors.
+var activeTheme = themes["default"]
+
+// Colour names accepted for names in the config, as well as numbers from 0
+// to 255
+var colorNames = map[string]ui.Color{
+	"black":   ui.ColorBlack,
+	"red":     ui.ColorRed,
+	"green":   ui.ColorGreen,
+	"yellow":  ui.ColorYellow,
+	"blue":    ui.ColorBlue,
+	"magenta": ui.ColorMagenta,
+	"cyan":    ui.ColorCyan,
+	"white":   ui.ColorWhite,
+}
+
+func parseTheme(name string) (theme, error) {
+	switch strings.ToLower(name) {
+	case "", "default":
+		return themes["default"], nil
+	case "high-contrast":
+		return themes["high-contrast"], nil
+	case "monochrome":
+		return themes["monochrome"], nil
+	case "colour-blind", "color-blind":
+		return themes["colour-blind"], nil
+	}
+	return themes["default"], fmt.Errorf("theme must be default, high-contrast, monochrome or colour-blind, not '%s'", name)
+}
+
+func parseColor(value string) (ui.Color, error) {
+	if c, ok := colorNames[strings.ToLower(value)]; ok {
+		return c, nil
+	}
+	code, err := strconv.Atoi(value)
+	if err != nil || code < 0 || code > 255 {
+		return ui.ColorClear, fmt.Errorf("colour must be a name such as blue or a number from 0 to 255, not '%s'", value)
+	}
+	return ui.Color(code), nil
+}
+
+// SetupColors applies the theme and name colours chosen in the config. If the
+// NO_COLOR environment variable is set, colour is turned off everywhere
+// instead, including in the output of other commands.
+func SetupColors() error {
+	noColor := os.Getenv("NO_COLOR") != ""
+	if noColor {
+		color.Reset, color.Bold, color.Red, color.Green, color.Yellow = "", "", "", "", ""
+		color.Blue, color.Purple, color.Cyan, color.Gray, color.White = "", "", "", "", ""
+		activeTheme = themes["monochrome"]
+		activeTheme.apply()
+		return nil
+	}
+
+	config, err := LoadConfig()
+	if err != nil {
+		return err
+	}
+	t, err := parseTheme(config.Theme)
+	if err != nil {
+		return err
+	}
+	t.pinned = map[string]ui.Color{}
+	for name, value := range config.NameColors {
+		c, err := parseColor(value)
+		if err != nil {
+			return fmt.Errorf("name colour for '%s': %w", name, err)
+		}
+		t.pinned[name] = c
+	}
+	activeTheme = t
+	activeTheme.apply()
+	return nil
+}
+
+// apply sets the colours termui gives new widgets, and the colours that can
+// be used in styled text such as [▲ 1h](fg:increase).
+func (t theme) apply() {
+	ui.Theme.Block.Border = ui.NewStyle(t.border)
+	ui.Theme.Block.Title = ui.NewStyle(t.border)
+	ui.Theme.Paragraph.Text = ui.NewStyle(ui.ColorClear)
+	ui.Theme.Tab.Active = t.tab
+	ui.Theme.Tab.Inactive = ui.NewStyle(t.border)
+	ui.StyleParserColorMap["text"] = t.text
+	ui.StyleParserColorMap["label"] = t.label
+	ui.StyleParserColorMap["increase"] = t.increase
+	ui.StyleParserColorMap["decrease"] = t.decrease
+}
+
+// nameColor returns the colour pinned to a name in the config, or the first
+// bar colour.
+func (t theme) nameColor(name string) ui.Color {
+	if c, ok := t.pinned[name]; ok {
+		return c
+	}
+	return t.bars[0]
+}
+
+// nameColors returns a colour for each name of a chart. Names with a colour
+// pinned in the config always get it, and the others take the theme's name
+// colours in order, skipping those pinned to names in the chart.
+func (t theme) nameColors(names []string) []ui.Color {
+	taken := map[ui.Color]bool{}
+	for _, name := range names {
+		if c, ok := t.pinned[name]; ok {
+			taken[c] = true
+		}
+	}
+	free := []ui.Color{}
+	for _, c := range t.names {
+		if !taken[c] {
+			free = append(free, c)
+		}
+	}
+	if len(free) == 0 {
+		free = t.names
+	}
+
+	colors := make([]ui.Color, len(names))
+	next := 0
+	for i, name := range names {
+		if c, ok := t.pinned[name]; ok {
+			colors[i] = c
+		} else {
+			colors[i] = free[next%len(free)]
+			next++
+		}
+	}
+	return colors
+}
+
+// ansiColor returns the escape code to print text in a colour, or nothing
+// for the default colour.
+func ansiColor(c ui.Color) string {
+	if c == ui.ColorClear {
+		return ""
+	}
+	return fmt.Sprintf("\033[38;5;%dm", c)
+}
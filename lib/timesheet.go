@@ -0,0 +1,245 @@
+package clockin
+
+import (
+	"database/sql"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"time"
+)
+
+// TimesheetOptions selects the week shown in a timesheet and how it is
+// written. An empty Week is the current week, and an empty WeekStart uses the
+// weekStart setting from the config.
+type TimesheetOptions struct {
+	Week      string
+	WeekStart string
+	Format    string
+}
+
+type timesheet struct {
+	days   []time.Time
+	names  []string
+	hours  map[string][]time.Duration
+	totals []time.Duration
+}
+
+// parseWeek returns the first day of an ISO week such as "2026-W41". Weeks
+// starting on Sunday begin the day before the ISO week's Monday.
+func parseWeek(week string, weekStart time.Weekday) (time.Time, error) {
+	var year, number int
+	_, err := fmt.Sscanf(strings.ToUpper(week), "%d-W%d", &year, &number)
+	if err != nil || number < 1 || number > 53 {
+		return time.Time{}, fmt.Errorf("invalid week '%s', expected e.g. 2026-W41", week)
+	}
+
+	// 4th January is always in the first ISO week
+	jan4 := time.Date(year, time.January, 4, 0, 0, 0, 0, time.UTC)
+	monday := jan4.AddDate(0, 0, -((int(jan4.Weekday())+6)%7)+(number-1)*7)
+	if y, _ := monday.ISOWeek(); y != year {
+		return time.Time{}, fmt.Errorf("%d has no week %d", year, number)
+	}
+	if weekStart == time.Sunday {
+		return monday.AddDate(0, 0, -1), nil
+	}
+	return monday, nil
+}
+
+// startOfWeek returns the first day of the week containing t.
+func startOfWeek(t time.Time, weekStart time.Weekday) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := (int(day.Weekday()) - int(weekStart) + 7) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// durationPerDay returns the time a session spent within each of the given
+// days, so sessions crossing midnight count towards both days.
+func durationPerDay(session Session, days []time.Time) []time.Duration {
+	durations := make([]time.Duration, len(days))
+	for i, day := range days {
+		dayStart := day
+		dayEnd := day.AddDate(0, 0, 1)
+		start := session.Start
+		if start.Before(dayStart) {
+			start = dayStart
+		}
+		finish := session.Finish
+		if finish.After(dayEnd) {
+			finish = dayEnd
+		}
+		if finish.After(start) {
+			durations[i] = finish.Sub(start)
+		}
+	}
+	return durations
+}
+
+func buildTimesheet(sessions []Session, weekStart time.Time) timesheet {
+	ts := timesheet{
+		days:   make([]time.Time, 7),
+		hours:  make(map[string][]time.Duration),
+		totals: make([]time.Duration, 7),
+	}
+	for i := range ts.days {
+		ts.days[i] = weekStart.AddDate(0, 0, i)
+	}
+
+	for _, session := range sessions {
+		if session.Finish.IsZero() {
+			continue
+		}
+		if _, ok := ts.hours[session.Name]; !ok {
+			ts.hours[session.Name] = make([]time.Duration, 7)
+			ts.names = append(ts.names, session.Name)
+		}
+		for i, duration := range durationPerDay(session, ts.days) {
+			ts.hours[session.Name][i] += duration
+			ts.totals[i] += duration
+		}
+	}
+
+	sort.Strings(ts.names)
+	return ts
+}
+
+func sumDurations(durations []time.Duration) time.Duration {
+	var total time.Duration
+	for _, duration := range durations {
+		total += duration
+	}
+	return total
+}
+
+func (ts timesheet) dayLabels() []string {
+	labels := make([]string, len(ts.days))
+	for i, day := range ts.days {
+		labels[i] = day.Format("Mon 02")
+	}
+	return labels
+}
+
+func formatTimesheetCell(duration time.Duration) string {
+	if duration == 0 {
+		return "-"
+	}
+	return formatHours(duration)
+}
+
+// rows returns the grid of formatted cells, one row per name followed by the
+// daily totals row.
+func (ts timesheet) rows(format func(time.Duration) string) [][]string {
+	rows := [][]string{}
+	for _, name := range ts.names {
+		row := []string{displayName(name)}
+		for _, duration := range ts.hours[name] {
+			row = append(row, format(duration))
+		}
+		row = append(row, format(sumDurations(ts.hours[name])))
+		rows = append(rows, row)
+	}
+
+	totals := []string{"Total"}
+	for _, duration := range ts.totals {
+		totals = append(totals, format(duration))
+	}
+	totals = append(totals, format(sumDurations(ts.totals)))
+	return append(rows, totals)
+}
+
+func (ts timesheet) writeText(w io.Writer) {
+	table := textTable{
+		headers:    append(append([]string{"Name"}, ts.dayLabels()...), "Total"),
+		alignRight: []bool{false, true, true, true, true, true, true, true, true},
+	}
+	rows := ts.rows(formatTimesheetCell)
+	for _, row := range rows[:len(rows)-1] {
+		table.addRow(row...)
+	}
+	table.addSeparator()
+	table.addRow(rows[len(rows)-1]...)
+	table.render(w)
+}
+
+func (ts timesheet) writeCSV(w io.Writer) error {
+	writer := csv.NewWriter(w)
+	header := []string{"Name"}
+	for _, day := range ts.days {
+		header = append(header, day.Format("2006-01-02"))
+	}
+	writer.Write(append(header, "Total"))
+
+	// Decimal hours are easier for other tools to read
+	decimalHours := func(duration time.Duration) string {
+		return fmt.Sprintf("%.2f", duration.Hours())
+	}
+	for _, row := range ts.rows(decimalHours) {
+		writer.Write(row)
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+// markdownCell escapes the pipes in the text of a Markdown table cell, which
+// would otherwise end the cell.
+func markdownCell(text string) string {
+	return strings.ReplaceAll(text, "|", `\|`)
+}
+
+func (ts timesheet) writeMarkdown(w io.Writer) {
+	header := append(append([]string{"Name"}, ts.dayLabels()...), "Total")
+	fmt.Fprintf(w, "| %s |\n", strings.Join(header, " | "))
+	fmt.Fprintf(w, "|---%s|\n", strings.Repeat("|--:", len(header)-1))
+	rows := ts.rows(formatTimesheetCell)
+	for i, row := range rows {
+		row[0] = markdownCell(row[0])
+		if i == len(rows)-1 {
+			for j := range row {
+				row[j] = "**" + row[j] + "**"
+			}
+		}
+		fmt.Fprintf(w, "| %s |\n", strings.Join(row, " | "))
+	}
+}
+
+// Timesheet prints a grid of the time recorded for each name on each day of a
+// week, with daily and weekly totals.
+func Timesheet(db *sql.DB, options TimesheetOptions) error {
+	weekStart, err := loadWeekStart(options.WeekStart)
+	if err != nil {
+		return err
+	}
+
+	var start time.Time
+	if options.Week == "" {
+		start = startOfWeek(CurrentTime(), weekStart)
+	} else {
+		start, err = parseWeek(options.Week, weekStart)
+		if err != nil {
+			return err
+		}
+	}
+	end := start.AddDate(0, 0, 7)
+
+	// Include sessions started the week before that finish within the week
+	sessions, err := querySessions(db, "deleted_at IS NULL AND finish IS NOT NULL AND start < ? AND finish > ?", end, start)
+	if err != nil {
+		return err
+	}
+
+	ts := buildTimesheet(sessions, start)
+	switch options.Format {
+	case "", "text":
+		fmt.Printf("Timesheet for %s to %s\n\n", start.Format("Mon 2 Jan 2006"), end.AddDate(0, 0, -1).Format("Mon 2 Jan 2006"))
+		ts.writeText(os.Stdout)
+	case "csv":
+		return ts.writeCSV(os.Stdout)
+	case "markdown", "md":
+		ts.writeMarkdown(os.Stdout)
+	default:
+		return fmt.Errorf("unknown format '%s'", options.Format)
+	}
+	return nil
+}
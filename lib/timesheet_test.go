@@ -0,0 +1,62 @@
+package clockin
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWeek(t *testing.T) {
+	tests := []struct {
+		week      string
+		weekStart time.Weekday
+		want      time.Time
+		wantErr   bool
+	}{
+		{week: "2026-W41", weekStart: time.Monday, want: dateAt(2026, 10, 5, 0)},
+		{week: "2026-w41", weekStart: time.Monday, want: dateAt(2026, 10, 5, 0)},
+		{week: "2026-W41", weekStart: time.Sunday, want: dateAt(2026, 10, 4, 0)},
+		// The first ISO week of 2026 starts in 2025
+		{week: "2026-W01", weekStart: time.Monday, want: dateAt(2025, 12, 29, 0)},
+		{week: "2026-W53", weekStart: time.Monday, want: dateAt(2026, 12, 28, 0)},
+		{week: "2025-W53", weekStart: time.Monday, wantErr: true},
+		{week: "2026-W54", weekStart: time.Monday, wantErr: true},
+		{week: "2026-W00", weekStart: time.Monday, wantErr: true},
+		{week: "2026-41", weekStart: time.Monday, wantErr: true},
+		{week: "W41", weekStart: time.Monday, wantErr: true},
+	}
+
+	for _, test := range tests {
+		got, err := parseWeek(test.week, test.weekStart)
+		if test.wantErr {
+			if err == nil {
+				t.Errorf("parseWeek(%q) = %s, want an error", test.week, got)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("parseWeek(%q) failed: %s", test.week, err)
+		} else if !got.Equal(test.want) {
+			t.Errorf("parseWeek(%q, %s) = %s, want %s", test.week, test.weekStart, got, test.want)
+		}
+	}
+}
+
+func TestTimesheetMarkdownEscapesPipes(t *testing.T) {
+	start := dateAt(2026, 10, 12, 9)
+	sessions := []Session{{Name: "a|b", Start: start, Finish: start.Add(time.Hour)}}
+	var out strings.Builder
+	buildTimesheet(sessions, dateAt(2026, 10, 12, 0)).writeMarkdown(&out)
+
+	lines := strings.Split(out.String(), "\n")
+	if len(lines) < 3 || !strings.HasPrefix(lines[2], `| a\|b |`) {
+		t.Fatalf("name row is not escaped:\n%s", out.String())
+	}
+	// Every row has the same number of unescaped pipes as the header
+	cells := strings.Count(lines[0], "|")
+	for _, line := range lines[1 : len(lines)-1] {
+		if n := strings.Count(strings.ReplaceAll(line, `\|`, ""), "|"); n != cells {
+			t.Errorf("row %q has %d cell borders, want %d", line, n, cells)
+		}
+	}
+}
@@ -0,0 +1,375 @@
+package clockin
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"github.com/TwiN/go-color"
+)
+
+// undoEntry records a single change made to a session by a command, with the
+// trashed previous version of the session for edits.
+type undoEntry struct {
+	action    string
+	sessionID int
+	versionID int
+}
+
+// errUndoStale is returned when a change can no longer be undone because
+// the session, or the old version it would be reverted to, has since been
+// permanently removed.
+var errUndoStale = errors.New("session has since been removed")
+
+// errNotInTrash is returned when an old version to restore is not in the
+// trash.
+var errNotInTrash = errors.New("not in the trash")
+
+// recordUndo stores the changes made by a command so that they can later be
+// reverted together by Undo.
+func recordUndo(db *sql.DB, command string, entries []undoEntry) error {
+	op := time.Now().UnixNano()
+	for _, entry := range entries {
+		_, err := db.Exec("INSERT INTO clockin_undo(op, command, action, session_id, version_id) VALUES (?, ?, ?, ?, ?)",
+			op, command, entry.action, entry.sessionID, entry.versionID)
+		if err != nil {
+			log.Printf("Error when recording command for undo: %s\n", err)
+			return err
+		}
+	}
+	return nil
+}
+
+func trashSession(db *sql.DB, id int) error {
+	res, err := db.Exec("UPDATE clockin SET deleted_at=NOW() WHERE id=? AND deleted_at IS NULL", id)
+	if err != nil {
+		log.Printf("Error when moving session to trash: %s\n", err)
+		return err
+	}
+	n, err := rowsAffected(res)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return fmt.Errorf("session %d does not exist", id)
+	}
+	return nil
+}
+
+// saveVersion stores a copy of a session in the trash as an old version of
+// that session, returning the ID of the copy.
+func saveVersion(ctx context.Context, tx *sql.Tx, session Session) (int, error) {
+	res, err := tx.ExecContext(ctx, "INSERT INTO clockin(name, start, finish, deleted_at, version_of) VALUES (?, ?, ?, NOW(), ?)",
+		session.Name, session.Start, nullTime(session.Finish), session.ID)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+// restoreVersion replaces a session's values with those of an old version
+// from the trash. The values being replaced are kept in the trash in turn, so
+// the restore can itself be reverted.
+func restoreVersion(db *sql.DB, versionID int) (Session, error) {
+	ctx, cancelfunc := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelfunc()
+	tx, err := db.BeginTx(ctx, nil)
+	if err != nil {
+		return Session{}, err
+	}
+	defer tx.Rollback()
+
+	var version Session
+	var finish sql.NullTime
+	err = tx.QueryRowContext(ctx, "SELECT version_of, name, start, finish FROM clockin WHERE id=? AND version_of IS NOT NULL",
+		versionID).Scan(&version.ID, &version.Name, &version.Start, &finish)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, fmt.Errorf("version %d is %w", versionID, errNotInTrash)
+	} else if err != nil {
+		return Session{}, err
+	}
+	version.Finish = finish.Time
+
+	var current Session
+	err = tx.QueryRowContext(ctx, "SELECT id, name, start, finish FROM clockin WHERE id=?",
+		version.ID).Scan(&current.ID, &current.Name, &current.Start, &finish)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Session{}, fmt.Errorf("session %d no longer exists", version.ID)
+	} else if err != nil {
+		return Session{}, err
+	}
+	current.Finish = finish.Time
+
+	_, err = saveVersion(ctx, tx, current)
+	if err != nil {
+		return Session{}, err
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE clockin SET name=?, start=?, finish=?, deleted_at=NULL WHERE id=?",
+		version.Name, version.Start, nullTime(version.Finish), version.ID)
+	if err != nil {
+		return Session{}, err
+	}
+	_, err = tx.ExecContext(ctx, "DELETE FROM clockin WHERE id=?", versionID)
+	if err != nil {
+		return Session{}, err
+	}
+	return version, tx.Commit()
+}
+
+// Audited action for reverting each kind of change
+var undoActions = map[string]string{
+	"start":  "delete",
+	"stop":   "edit",
+	"delete": "restore",
+	"edit":   "edit",
+}
+
+// discardStaleUndo marks commands as undone when a session or old version
+// they changed has been permanently removed, as they can no longer be
+// reverted.
+func discardStaleUndo(ex execer) error {
+	_, err := ex.Exec(`UPDATE clockin_undo u JOIN clockin_undo s ON s.op = u.op
+		LEFT JOIN clockin c ON c.id = s.session_id
+		LEFT JOIN clockin v ON v.id = s.version_id
+		SET u.undone = true
+		WHERE u.undone = false AND (c.id IS NULL OR (s.action = 'edit' AND v.id IS NULL))`)
+	if err != nil {
+		log.Printf("Error when discarding commands that can no longer be undone: %s\n", err)
+	}
+	return err
+}
+
+// undoChanged checks that reverting a change updated the session, returning
+// errUndoStale if it no longer exists in the state the change left it in.
+func undoChanged(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	n, err := rowsAffected(res)
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return errUndoStale
+	}
+	return nil
+}
+
+func undoEntryChange(db *sql.DB, entry undoEntry) error {
+	before, err := sessionState(db, entry.sessionID)
+	if err != nil {
+		return err
+	}
+	if before.ID == 0 {
+		return errUndoStale
+	}
+
+	switch entry.action {
+	case "start":
+		err = trashSession(db, entry.sessionID)
+	case "stop":
+		err = undoChanged(db.Exec("UPDATE clockin SET finish=NULL WHERE id=? AND deleted_at IS NULL", entry.sessionID))
+	case "delete":
+		err = undoChanged(db.Exec("UPDATE clockin SET deleted_at=NULL WHERE id=? AND deleted_at IS NOT NULL", entry.sessionID))
+	case "edit":
+		_, err = restoreVersion(db, entry.versionID)
+		if errors.Is(err, errNotInTrash) {
+			err = errUndoStale
+		}
+	default:
+		err = fmt.Errorf("unknown action '%s'", entry.action)
+	}
+	if err != nil {
+		return err
+	}
+
+	after, err := sessionState(db, entry.sessionID)
+	if err != nil {
+		return err
+	}
+	if entry.action == "start" {
+		// The session was moved to the trash
+		after = Session{}
+	}
+	return auditChange(db, "undo", undoActions[entry.action], before, after)
+}
+
+// Undo reverts the most recent start, stop, edit, delete or switch command
+// that has not already been undone. Changes to sessions that have since been
+// permanently removed are skipped.
+func Undo(db *sql.DB) error {
+	var op int64
+	var command string
+	err := db.QueryRow("SELECT op, command FROM clockin_undo WHERE undone=false ORDER BY id DESC LIMIT 1").Scan(&op, &command)
+	if errors.Is(err, sql.ErrNoRows) {
+		fmt.Println(color.Ize(color.Red, "Nothing to undo"))
+		return nil
+	} else if err != nil {
+		log.Printf("Error when finding last command: %s\n", err)
+		return err
+	}
+
+	rows, err := db.Query("SELECT action, session_id, version_id FROM clockin_undo WHERE op=? ORDER BY id DESC", op)
+	if err != nil {
+		return err
+	}
+	entries := []undoEntry{}
+	for rows.Next() {
+		var entry undoEntry
+		err = rows.Scan(&entry.action, &entry.sessionID, &entry.versionID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		entries = append(entries, entry)
+	}
+	rows.Close()
+
+	// Changes are reverted in the reverse order they were made
+	undone := 0
+	for _, entry := range entries {
+		err = undoEntryChange(db, entry)
+		if errors.Is(err, errUndoStale) {
+			fmt.Printf(color.Ize(color.Red, "Cannot undo %s of session %d, as it has since been removed\n"),
+				entry.action, entry.sessionID)
+			continue
+		} else if err != nil {
+			log.Printf("Error when undoing %s of session %d: %s\n", entry.action, entry.sessionID, err)
+			return err
+		}
+		undone++
+	}
+
+	_, err = db.Exec("UPDATE clockin_undo SET undone=true WHERE op=?", op)
+	if err != nil {
+		return err
+	}
+
+	if undone > 0 {
+		fmt.Printf(color.Ize(color.Green, "Undid %s\n"), command)
+	}
+	return nil
+}
+
+func ListTrash(db *sql.DB) error {
+	rows, err := db.Query("SELECT id, name, start, deleted_at, version_of, finish FROM clockin WHERE deleted_at IS NOT NULL ORDER BY deleted_at DESC")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	count := 0
+	for rows.Next() {
+		var session Session
+		var deletedAt time.Time
+		var versionOf sql.NullInt64
+		var finish sql.NullTime
+		err = rows.Scan(&session.ID, &session.Name, &session.Start, &deletedAt, &versionOf, &finish)
+		if err != nil {
+			return err
+		}
+		session.Finish = finish.Time
+
+		finishStr := color.Ize(color.Yellow, "running")
+		if !session.Finish.IsZero() {
+			finishStr = session.Finish.Format("2006-01-02 15:04:05")
+		}
+		fmt.Printf("[%d] %s %s - %s", session.ID, displayName(session.Name),
+			session.Start.Format("2006-01-02 15:04:05"), finishStr)
+		if versionOf.Valid {
+			fmt.Printf(color.Ize(color.Blue, " (old version of [%d])"), versionOf.Int64)
+		}
+		fmt.Printf(color.Ize(color.Red, " deleted %s\n"), deletedAt.Format("2006-01-02 15:04:05"))
+		count++
+	}
+
+	if count == 0 {
+		fmt.Println(color.Ize(color.Green, "Trash is empty"))
+	}
+	return rows.Err()
+}
+
+// RestoreFromTrash restores a deleted session, or for an old version of an
+// edited session, reverts the session to that version.
+func RestoreFromTrash(db *sql.DB, id int) error {
+	var versionOf sql.NullInt64
+	err := db.QueryRow("SELECT version_of FROM clockin WHERE id=? AND deleted_at IS NOT NULL", id).Scan(&versionOf)
+	if errors.Is(err, sql.ErrNoRows) {
+		return fmt.Errorf("session %d is not in the trash", id)
+	} else if err != nil {
+		return err
+	}
+
+	if versionOf.Valid {
+		before, err := sessionState(db, int(versionOf.Int64))
+		if err != nil {
+			return err
+		}
+		session, err := restoreVersion(db, id)
+		if err != nil {
+			log.Printf("Error when restoring old version: %s\n", err)
+			return err
+		}
+		err = auditChange(db, "trash restore", "edit", before, session)
+		if err != nil {
+			return err
+		}
+		fmt.Printf(color.Ize(color.Green, "Restored session [%d] %s to an old version\n"), session.ID, displayName(session.Name))
+		return nil
+	}
+
+	_, err = db.Exec("UPDATE clockin SET deleted_at=NULL WHERE id=?", id)
+	if err != nil {
+		log.Printf("Error when restoring session: %s\n", err)
+		return err
+	}
+	session, err := sessionState(db, id)
+	if err != nil {
+		return err
+	}
+	err = auditChange(db, "trash restore", "restore", Session{}, session)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Restored session [%d]\n"), id)
+	return nil
+}
+
+// EmptyTrash permanently deletes sessions that were moved to the trash more
+// than olderThan ago, or everything in the trash if olderThan is zero.
+func EmptyTrash(db *sql.DB, olderThan time.Duration) error {
+	cutoff := CurrentTime().Add(-olderThan)
+	// Old versions of edited sessions are not sessions in their own right, so
+	// only the permanent removal of deleted sessions is audited
+	purged, err := querySessions(db, "deleted_at IS NOT NULL AND deleted_at <= ? AND version_of IS NULL", cutoff)
+	if err != nil {
+		return err
+	}
+
+	res, err := db.Exec("DELETE FROM clockin WHERE deleted_at IS NOT NULL AND deleted_at <= ?", cutoff)
+	if err != nil {
+		log.Printf("Error when emptying trash: %s\n", err)
+		return err
+	}
+
+	n, err := rowsAffected(res)
+	if err != nil {
+		return err
+	}
+	for _, session := range purged {
+		err = auditChange(db, "trash empty", "purge", session, Session{})
+		if err != nil {
+			return err
+		}
+	}
+	err = discardStaleUndo(db)
+	if err != nil {
+		return err
+	}
+	fmt.Printf(color.Ize(color.Green, "Permanently deleted %d sessions from the trash\n"), n)
+	return nil
+}
@@ -0,0 +1,117 @@
+package clockin
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/TwiN/go-color"
+	ui "github.com/gizak/termui/v3"
+	"github.com/gizak/termui/v3/widgets"
+	"github.com/guptarohit/asciigraph"
+)
+
+// TrendOptions selects the number of days up to today shown in a trend
+// graph, and the name of the sessions included. An empty Name includes every
+// session.
+type TrendOptions struct {
+	Days int
+	Name string
+}
+
+// Number of days averaged by the moving average line
+const movingAverageDays = 7
+
+// Number of days shown in the trend tab of the stats page
+const trendTabDays = 90
+
+// trendDays returns the given number of days, ending today.
+func trendDays(now time.Time, n int) []time.Time {
+	today := startOfDay(now)
+	return daysBetween(today.AddDate(0, 0, 1-n), today)
+}
+
+func dailyHours(sessions []Session, days []time.Time) []float64 {
+	hours := []float64{}
+	for _, total := range dailyTotals(sessions, days) {
+		hours = append(hours, roundFloat(total.Hours(), 2))
+	}
+	return hours
+}
+
+// movingAverage returns the average of each value and the values before it,
+// up to window values in total.
+func movingAverage(values []float64, window int) []float64 {
+	averages := make([]float64, len(values))
+	var sum float64
+	for i, value := range values {
+		sum += value
+		if i >= window {
+			sum -= values[i-window]
+		}
+		count := i + 1
+		if count > window {
+			count = window
+		}
+		averages[i] = roundFloat(sum/float64(count), 2)
+	}
+	return averages
+}
+
+func trendRange(now time.Time) DateRange {
+	return DateRange{Start: startOfDay(now).AddDate(0, 0, 1-trendTabDays)}
+}
+
+func trendChart(page pageData) ui.Drawable {
+	hours := dailyHours(page.sessions, trendDays(CurrentTime(), trendTabDays))
+
+	p := widgets.NewPlot()
+	p.Title = fmt.Sprintf("Hours per day over the last %d days, with %d-day average", trendTabDays, movingAverageDays)
+	p.Data = [][]float64{hours, movingAverage(hours, movingAverageDays)}
+	p.LineColors = activeTheme.lines
+	p.AxesColor = activeTheme.axes
+	// The plot cannot scale a graph of only zeros
+	if max, _ := ui.GetMaxFloat64From2dSlice(p.Data); max == 0 {
+		p.MaxVal = 1
+	}
+	return p
+}
+
+// Trend prints a line graph of the hours recorded on each day, with a moving
+// average to show the trend through busy and quiet days.
+func Trend(db *sql.DB, options TrendOptions) error {
+	if options.Days < 2 {
+		return errors.New("a trend needs at least 2 days")
+	}
+	days := trendDays(CurrentTime(), options.Days)
+
+	where := "deleted_at IS NULL AND finish IS NOT NULL AND finish > ?"
+	args := []interface{}{days[0]}
+	if options.Name != "" {
+		where += " AND name = ?"
+		args = append(args, options.Name)
+	}
+	sessions, err := querySessions(db, where, args...)
+	if err != nil {
+		return err
+	}
+
+	hours := dailyHours(sessions, days)
+	title := fmt.Sprintf("Hours per day from %s to %s", days[0].Format("2 Jan 2006"), days[len(days)-1].Format("2 Jan 2006"))
+	if options.Name != "" {
+		title += " for " + options.Name
+	}
+	total := activeTheme.lines[0]
+	average := activeTheme.lines[len(activeTheme.lines)-1]
+	plotOptions := []asciigraph.Option{asciigraph.Height(15), asciigraph.Precision(1)}
+	if total != ui.ColorClear {
+		plotOptions = append(plotOptions, asciigraph.SeriesColors(asciigraph.AnsiColor(total), asciigraph.AnsiColor(average)))
+	}
+	graph := asciigraph.PlotMany([][]float64{hours, movingAverage(hours, movingAverageDays)}, plotOptions...)
+
+	fmt.Printf("%s\n\n%s\n\n", title, graph)
+	fmt.Printf("%s daily total   %s %d-day average\n",
+		color.Ize(ansiColor(total), "───"), color.Ize(ansiColor(average), "───"), movingAverageDays)
+	return nil
+}
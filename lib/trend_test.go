@@ -0,0 +1,27 @@
+package clockin
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestMovingAverage(t *testing.T) {
+	tests := []struct {
+		values []float64
+		window int
+		want   []float64
+	}{
+		{values: []float64{}, window: 7, want: []float64{}},
+		{values: []float64{1, 2, 3, 4}, window: 1, want: []float64{1, 2, 3, 4}},
+		{values: []float64{1, 2, 3, 4}, window: 2, want: []float64{1, 1.5, 2.5, 3.5}},
+		{values: []float64{3, 6, 9, 0}, window: 3, want: []float64{3, 4.5, 6, 5}},
+		{values: []float64{1, 0, 0}, window: 7, want: []float64{1, 0.5, 0.33}},
+	}
+
+	for _, test := range tests {
+		got := movingAverage(test.values, test.window)
+		if !reflect.DeepEqual(got, test.want) {
+			t.Errorf("movingAverage(%v, %d) = %v, want %v", test.values, test.window, got, test.want)
+		}
+	}
+}
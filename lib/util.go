@@ -1,6 +1,8 @@
 package clockin
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 
 	"github.com/hako/durafmt"
@@ -12,9 +14,12 @@ func Check(err error) {
 	}
 }
 
+// clock returns the time now. Tests replace it to work at a fixed time.
+var clock = time.Now
+
 func CurrentTime() time.Time {
 	// Parse time to force into local time
-	now, err := time.Parse("2006-01-02 15:04:05", time.Now().Format("2006-01-02 15:04:05"))
+	now, err := time.Parse("2006-01-02 15:04:05", clock().Format("2006-01-02 15:04:05"))
 	Check(err)
 	return now
 }
@@ -22,3 +27,60 @@ func CurrentTime() time.Time {
 func formatDuration(duration time.Duration, limitFirstN int) string {
 	return durafmt.Parse(duration).LimitFirstN(limitFirstN).String()
 }
+
+func wallClock(t time.Time) time.Time {
+	// Sessions are stored as local wall clock times, so convert to the local
+	// time zone and force into UTC in the same way as CurrentTime
+	local := t.In(time.Local)
+	return time.Date(local.Year(), local.Month(), local.Day(), local.Hour(),
+		local.Minute(), local.Second(), 0, time.UTC)
+}
+
+func ParseDate(date string) (time.Time, error) {
+	return time.Parse("2006-01-02", date)
+}
+
+// nullTime converts a zero time into a NULL database value.
+func nullTime(t time.Time) interface{} {
+	if t.IsZero() {
+		return nil
+	}
+	return t
+}
+
+func displayName(name string) string {
+	if name == "" {
+		return "none"
+	}
+	return name
+}
+
+// ParseDateTime parses a local date and time, e.g. "2026-10-01 09:30".
+func ParseDateTime(datetime string) (time.Time, error) {
+	for _, layout := range []string{"2006-01-02 15:04:05", "2006-01-02 15:04", "2006-01-02T15:04:05", "2006-01-02T15:04"} {
+		t, err := time.Parse(layout, datetime)
+		if err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("invalid date and time '%s'", datetime)
+}
+
+// ParseDays parses a length of time given in days or weeks, e.g. "30d" or
+// "2w".
+func ParseDays(value string) (int, error) {
+	if len(value) < 2 {
+		return 0, fmt.Errorf("invalid number of days '%s'", value)
+	}
+	n, err := strconv.Atoi(value[:len(value)-1])
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("invalid number of days '%s'", value)
+	}
+	switch value[len(value)-1] {
+	case 'd':
+		return n, nil
+	case 'w':
+		return n * 7, nil
+	}
+	return 0, fmt.Errorf("invalid number of days '%s'", value)
+}